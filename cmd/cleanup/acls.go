@@ -0,0 +1,155 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/confluent"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	delete_redundant bool
+	acls_format      string
+	acls_file        string
+	principal_map    []string
+)
+
+var aclCmd = &cobra.Command{
+	Use:     "acls",
+	Aliases: []string{"acl"},
+	Short:   "Clean ACLs ",
+	Long:    ` Command to Clean Confluent Cloud ACLs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runCleaner(newConfluentClean(cmd).ACLs())
+	},
+}
+
+var redundantAclsCmd = &cobra.Command{
+	Use:   "redundant",
+	Short: "Detect redundant ACLs",
+	Long: ` Command to list the ACLs covered by a broader ACL of the same principal: ACLs shadowed by a PREFIXED
+ or * ACL, and operations superseded by ALL or by an implying operation (e.g. READ implies DESCRIBE).
+ The remaining ACLs are the minimal equivalent set. With --delete the redundant ACLs are deleted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := newConfluentClean(cmd).RedundantACLs()
+		if !delete_redundant {
+			defer finishRun(c.Name())
+			runScan(context.Background(), c, views[c.Name()])
+			return
+		}
+		runCleaner(c)
+	},
+}
+
+var exportAclsCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the ACLs of a cluster",
+	Long: ` Command to write the ACLs of the cluster as JSON or YAML, read by acls import, or as a script of
+ confluent kafka acl create commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if acls_file == "" {
+			fmt.Println("An export file is required: --file")
+			os.Exit(1)
+		}
+		if err := confluent.ValidateExportFormat(acls_format); err != nil {
+			fmt.Println("Error exporting ACLs:", err)
+			os.Exit(1)
+		}
+		cflt := newConfluentClean(cmd)
+		defer closeRun()
+		acls, err := cflt.CloudAPI.GetACLs()
+		if err != nil {
+			fmt.Println("Error getting ACLs:", err)
+			exit(1)
+		}
+		if err := writeACLExport(acls_file, confluent.NewACLExport(cluster, acls), acls_format); err != nil {
+			fmt.Println("Error exporting ACLs:", err)
+			exit(1)
+		}
+		fmt.Println("Exported", len(acls), "ACLs to", acls_file)
+	},
+}
+
+// writeACLExport replaces the file atomically, a failed export keeps the previous one
+func writeACLExport(path string, export confluent.ACLExport, format string) error {
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(out, format); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+var importAclsCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the ACLs of an export",
+	Long: ` Command to create the ACLs of a JSON or YAML export missing in the cluster. The principals of the export
+ are replaced with --principal_map old=new, e.g. sa-old01=sa-new01, to import the ACLs of a rebuilt cluster.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if acls_file == "" {
+			fmt.Println("An ACL export is required: --file")
+			os.Exit(1)
+		}
+		principals, err := parsePrincipalMap(principal_map)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		export, err := confluent.ReadACLExport(acls_file)
+		if err != nil {
+			fmt.Println("Error reading ACL export:", err)
+			os.Exit(1)
+		}
+		acls, err := export.Bindings(principals)
+		if err != nil {
+			fmt.Println("Error reading ACL export", acls_file, ":", err)
+			os.Exit(1)
+		}
+		runCleaner(newConfluentClean(cmd).ImportACLs(acls))
+	},
+}
+
+// parsePrincipalMap parses old=new pairs, the User: prefix is optional
+func parsePrincipalMap(values []string) (map[string]string, error) {
+	principals := make(map[string]string)
+	for _, value := range values {
+		old, new, ok := strings.Cut(value, "=")
+		if !ok || old == "" || new == "" {
+			return nil, fmt.Errorf("invalid principal mapping %q, expected old=new, e.g. sa-old01=sa-new01", value)
+		}
+		principals[userPrincipal(old)] = userPrincipal(new)
+	}
+	return principals, nil
+}
+
+func userPrincipal(principal string) string {
+	if strings.Contains(principal, ":") {
+		return principal
+	}
+	return "User:" + principal
+}
+
+func init() {
+	redundantAclsCmd.Flags().BoolVarP(&delete_redundant, "delete", "", false, "Delete the redundant ACLs")
+	aclCmd.AddCommand(redundantAclsCmd)
+
+	exportAclsCmd.Flags().StringVarP(&acls_format, "format", "", confluent.JSONFormat, "Export format: json, yaml or script")
+	exportAclsCmd.Flags().StringVarP(&acls_file, "file", "", "", "Export file")
+	aclCmd.AddCommand(exportAclsCmd)
+
+	importAclsCmd.Flags().StringVarP(&acls_file, "file", "", "", "ACL export file (JSON or YAML)")
+	importAclsCmd.Flags().StringSliceVarP(&principal_map, "principal_map", "", nil, "Principals to replace, old=new (e.g. sa-old01=sa-new01)")
+	aclCmd.AddCommand(importAclsCmd)
+}
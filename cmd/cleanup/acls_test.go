@@ -0,0 +1,51 @@
+package cleanup
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mcolomer/cloud-keeping/pkg/confluent"
+)
+
+func TestParsePrincipalMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"service accounts", []string{"sa-old01=sa-new01"}, map[string]string{"User:sa-old01": "User:sa-new01"}, false},
+		{"prefixed principals", []string{"User:u-old=User:u-new", "Group:ops=User:pool-1"}, map[string]string{"User:u-old": "User:u-new", "Group:ops": "User:pool-1"}, false},
+		{"missing new", []string{"sa-old01="}, nil, true},
+		{"no pair", []string{"sa-old01"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePrincipalMap(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePrincipalMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, tt.want) {
+				t.Errorf("parsePrincipalMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteACLExportKeepsPrevious(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acls.json")
+	if err := os.WriteFile(path, []byte("previous"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeACLExport(path, confluent.NewACLExport("lkc-1", nil), "xml"); err == nil {
+		t.Fatal("writeACLExport() error = nil, want an unknown format error")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "previous" {
+		t.Errorf("export file = %q after a failed export, want the previous one", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left after a failed export: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package cleanup
+
+import (
+	"encoding/json"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/audit"
+	"mcolomer/cloud-keeping/pkg/outputs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	audit_since  time.Duration
+	audit_filter audit.Filter
+	audit_output string
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Cleanup audit log",
+	Long:  ` Commands to query the audit log of every detection and deletion.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show audit log entries",
+	Long:  ` Show the audit log entries matching the filters.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := audit_filter
+		filter.Event = strings.ToUpper(filter.Event)
+		if audit_since > 0 {
+			filter.Since = time.Now().Add(-audit_since)
+		}
+		entries, err := audit.Read(audit_log, filter)
+		if err != nil {
+			fmt.Println("Error reading audit log:", err)
+			os.Exit(1)
+		}
+		if audit_output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			for _, entry := range entries {
+				encoder.Encode(entry)
+			}
+			return
+		}
+		rows := make([][]interface{}, len(entries))
+		for i, e := range entries {
+			rows[i] = []interface{}{e.Timestamp.Local().Format(time.DateTime), e.Operator, e.Cluster, e.Event, e.Kind, e.Resource, e.Error}
+		}
+		outputs.NewTable([]string{"Timestamp", "Operator", "Cluster", "Event", "Kind", "Resource", "Error"}, rows)
+	},
+}
+
+func init() {
+	auditShowCmd.Flags().DurationVarP(&audit_since, "since", "", 0, "Only entries newer than this duration, e.g. 24h")
+	auditShowCmd.Flags().StringVarP(&audit_filter.Cluster, "cluster", "", "", "Filter by cluster Id")
+	auditShowCmd.Flags().StringVarP(&audit_filter.Kind, "kind", "", "", "Filter by resource kind: topic, acl, api-key, role-binding, connector")
+	auditShowCmd.Flags().StringVarP(&audit_filter.Event, "event", "", "", "Filter by event: DETECTED, DELETED, DELETE_FAILED")
+	auditShowCmd.Flags().StringVarP(&audit_filter.Resource, "resource", "", "", "Filter by resource")
+	auditShowCmd.Flags().StringVarP(&audit_filter.Operator, "operator", "", "", "Filter by operator")
+	auditShowCmd.Flags().StringVarP(&audit_output, "output", "o", "table", "Output format: table or json")
+	auditCmd.AddCommand(auditShowCmd)
+	rootCmd.AddCommand(auditCmd)
+}
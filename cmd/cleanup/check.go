@@ -0,0 +1,191 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/client"
+	"mcolomer/cloud-keeping/pkg/outputs"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the check command
+const (
+	exitCheckFailed = 1
+	exitCheckError  = 2
+	exitCheckAuth   = 3
+)
+
+var (
+	check_cleaners   []string
+	check_thresholds []string
+	junit_file       string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Fail when the cluster has too many candidates",
+	Long: ` Command to gate CI pipelines on the cleanliness of a cluster. The scans are read only, nothing is deleted.
+ A cleaner fails when its candidates exceed its threshold, --threshold topics=20 allows up to 20 inactive topics,
+ and the default threshold is 0.
+ Exit codes: 0 passed, 1 thresholds exceeded, 2 errors, 3 authentication or authorization failures.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		thresholds, err := parseThresholds(check_thresholds)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(exitCheckError)
+		}
+		if !Validate() {
+			fmt.Println("Error validating configuration.")
+			os.Exit(exitCheckError)
+		}
+		cflt, err := connectConfluent()
+		if err != nil {
+			fmt.Println("Error connecting to Confluent Cloud:", err)
+			os.Exit(checkExitCode(err))
+		}
+		confluentClean = cflt
+		cleaners := map[string]cleaner.Cleaner{}
+		for _, c := range cflt.AllCleaners() {
+			cleaners[c.Name()] = c
+		}
+		for _, name := range check_cleaners {
+			if _, ok := cleaners[name]; !ok {
+				fmt.Println("Error: unknown cleaner", name)
+				exit(exitCheckError)
+			}
+		}
+		code := 0
+		rows := make([][]interface{}, 0, len(check_cleaners))
+		junit := outputs.JUnitTestSuites{Name: "cleanup check " + cluster}
+		for _, name := range check_cleaners {
+			result := runCheck(cleaners[name], thresholds[name])
+			rows = append(rows, []interface{}{name, result.candidates, thresholds[name], result.status})
+			junit.Suites = append(junit.Suites, result.suite)
+			if result.err != nil {
+				code = max(code, checkExitCode(result.err))
+			} else if result.candidates > thresholds[name] {
+				code = max(code, exitCheckFailed)
+			}
+		}
+		fmt.Println("\n Check results")
+		outputs.NewTable([]string{"Cleaner", "Candidates", "Threshold", "Result"}, rows)
+		if junit_file != "" {
+			if err := outputs.WriteJUnit(junit_file, junit); err != nil {
+				fmt.Println("Error writing JUnit report", junit_file, ":", err)
+				exit(exitCheckError)
+			}
+			fmt.Println("JUnit report written to", junit_file)
+		}
+		exit(code)
+	},
+}
+
+// checkResult of a cleaner
+type checkResult struct {
+	candidates int
+	status     string
+	err        error
+	suite      outputs.JUnitTestSuite
+}
+
+// runCheck scans a cleaner, every candidate is a test case failing when the threshold is exceeded
+func runCheck(c cleaner.Cleaner, threshold int) checkResult {
+	v := views[c.Name()]
+	fmt.Println(v.title)
+	result := checkResult{suite: outputs.JUnitTestSuite{Name: c.Name()}}
+	report, err := c.Scan(context.Background())
+	result.suite.Time = report.Duration.Seconds()
+	if err != nil {
+		fmt.Println("Error scanning", c.Name(), ":", err)
+		result.status, result.err = "ERROR", err
+		result.suite.Cases = append(result.suite.Cases, outputs.JUnitTestCase{
+			Name:      "scan",
+			Classname: checkClassname(c.Name()),
+			Error:     &outputs.JUnitMessage{Message: err.Error()},
+		})
+		return result
+	}
+	renderReport(v, report)
+	candidates := report.Candidates()
+	result.candidates = len(candidates)
+	result.status = "PASSED"
+	exceeded := len(candidates) > threshold
+	if exceeded {
+		result.status = "FAILED"
+	}
+	for _, f := range candidates {
+		message := &outputs.JUnitMessage{Message: f.Status, Text: findingText(f)}
+		tc := outputs.JUnitTestCase{Name: f.Id, Classname: checkClassname(c.Name())}
+		if exceeded {
+			tc.Failure = message
+		} else {
+			message.Message = fmt.Sprintf("%s, within the threshold of %d", f.Status, threshold)
+			tc.Skipped = message
+		}
+		result.suite.Cases = append(result.suite.Cases, tc)
+	}
+	if len(candidates) == 0 {
+		result.suite.Cases = append(result.suite.Cases, outputs.JUnitTestCase{Name: "no candidates", Classname: checkClassname(c.Name())})
+	}
+	return result
+}
+
+func checkClassname(cleanerName string) string {
+	return fmt.Sprintf("cleanup.%s.%s", cluster, cleanerName)
+}
+
+// findingText lists the attributes of a finding
+func findingText(f cleaner.Finding) string {
+	lines := make([]string, 0, len(f.Attributes))
+	for name, value := range f.Attributes {
+		lines = append(lines, name+"="+value)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// parseThresholds parses cleaner=max pairs
+func parseThresholds(values []string) (map[string]int, error) {
+	thresholds := make(map[string]int)
+	for _, value := range values {
+		name, max, ok := strings.Cut(value, "=")
+		n, err := strconv.Atoi(max)
+		if !ok || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid threshold %q, expected cleaner=max, e.g. topics=20", value)
+		}
+		thresholds[name] = n
+	}
+	return thresholds, nil
+}
+
+// checkExitCode distinguishes the authentication and authorization failures of the APIs and of the cluster
+func checkExitCode(err error) int {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+		return exitCheckAuth
+	}
+	var kafkaErr kafka.Error
+	if errors.As(err, &kafkaErr) {
+		switch kafkaErr.Code() {
+		case kafka.ErrSaslAuthenticationFailed, kafka.ErrTopicAuthorizationFailed, kafka.ErrClusterAuthorizationFailed:
+			return exitCheckAuth
+		}
+	}
+	return exitCheckError
+}
+
+func init() {
+	checkCmd.Flags().StringSliceVarP(&check_cleaners, "cleaners", "", []string{"topics", "acls", "service-accounts", "connectors"}, "Cleaners to check: topics, acls, service-accounts, api-keys, role-bindings, connectors, schemas")
+	checkCmd.Flags().StringSliceVarP(&check_thresholds, "threshold", "", nil, "Candidates allowed per cleaner, cleaner=max (e.g. topics=20), default 0")
+	checkCmd.Flags().StringVarP(&junit_file, "junit", "", "", "JUnit XML report file, a test case per candidate")
+	confluentCmd.AddCommand(checkCmd)
+}
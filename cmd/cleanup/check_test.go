@@ -0,0 +1,58 @@
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"mcolomer/cloud-keeping/pkg/client"
+	"net/http"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func TestParseThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string]int
+		wantErr bool
+	}{
+		{"thresholds", []string{"topics=20", "acls=0"}, map[string]int{"topics": 20, "acls": 0}, false},
+		{"missing max", []string{"topics"}, nil, true},
+		{"not a number", []string{"topics=many"}, nil, true},
+		{"negative", []string{"topics=-1"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseThresholds(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseThresholds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, tt.want) {
+				t.Errorf("parseThresholds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unauthorized", &client.APIError{StatusCode: http.StatusUnauthorized}, exitCheckAuth},
+		{"forbidden wrapped", fmt.Errorf("getting topics: %w", &client.APIError{StatusCode: http.StatusForbidden}), exitCheckAuth},
+		{"sasl", kafka.NewError(kafka.ErrSaslAuthenticationFailed, "auth failed", false), exitCheckAuth},
+		{"timeout", kafka.NewError(kafka.ErrTimedOut, "timed out", false), exitCheckError},
+		{"other", errors.New("boom"), exitCheckError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkExitCode(tt.err); got != tt.want {
+				t.Errorf("checkExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package cleanup
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var connectorsCmd = &cobra.Command{
+	Use:     "connectors",
+	Aliases: []string{"connect", "cnct"},
+	Short:   "Clean Connectors ",
+	Long:    ` Command to Clean failed Confluent Cloud Connectors.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runCleaner(newConfluentClean(cmd).Connectors())
+	},
+}
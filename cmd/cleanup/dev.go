@@ -0,0 +1,54 @@
+package cleanup
+
+import (
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/fakecloud"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	seed_file string
+	fake_addr string
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Development tools",
+	Long:  ` Tools to demo and test cleanup runs without a Confluent Cloud organization.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var fakeServerCmd = &cobra.Command{
+	Use:   "fake-server",
+	Short: "Run a fake Confluent Cloud API server",
+	Long: ` Serves the cmk, iam, kafka v3, connect and telemetry endpoints used by the cleaners from a seed YAML file.
+ Point the cleaners to it using --confluent_endpoint and --metrics_endpoint.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		seed, err := fakecloud.LoadSeed(seed_file)
+		if err != nil {
+			fmt.Println("Error loading seed file:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n Fake Confluent Cloud listening on http://%s \n", fake_addr)
+		fmt.Println("  - Environment: ", seed.Environment)
+		for _, c := range seed.Clusters {
+			fmt.Println("  - Cluster: ", c.Id)
+		}
+		if err := http.ListenAndServe(fake_addr, fakecloud.NewServer(seed)); err != nil {
+			fmt.Println("Error running fake server:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	fakeServerCmd.Flags().StringVarP(&seed_file, "seed", "", "seed.yaml", "Seed YAML file describing the fake organization")
+	fakeServerCmd.Flags().StringVarP(&fake_addr, "addr", "", "localhost:8080", "Listen address")
+	devCmd.AddCommand(fakeServerCmd)
+	rootCmd.AddCommand(devCmd)
+}
@@ -0,0 +1,29 @@
+package cleanup
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var iamCmd = &cobra.Command{
+	Use:     "iam",
+	Aliases: []string{"sa"},
+	Short:   "Clean Service Accounts ",
+	Long:    ` Command to Clean Confluent Cloud inactive Service Accounts API Keys and Role Bindings.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runCleaner(newConfluentClean(cmd).ServiceAccounts())
+	},
+}
+
+var roleBindingsCmd = &cobra.Command{
+	Use:   "role-bindings",
+	Short: "Clean Role Bindings of deleted resources",
+	Long: ` Command to list the role bindings of the environment whose cluster, topic, consumer group, connector or subject
+ doesn't exist anymore, and delete them once confirmed. Subjects are checked with the Schema Registry credentials.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runCleaner(newConfluentClean(cmd).RoleBindings())
+	},
+}
+
+func init() {
+	iamCmd.AddCommand(roleBindingsCmd)
+}
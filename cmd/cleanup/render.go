@@ -0,0 +1,384 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"mcolomer/cloud-keeping/pkg/confluent"
+	"mcolomer/cloud-keeping/pkg/outputs"
+	"mcolomer/cloud-keeping/pkg/state"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// view describes how the report of a cleaner is rendered
+type view struct {
+	title    string
+	header   []string
+	row      func(f cleaner.Finding) []interface{}
+	question string
+	empty    string
+	// summary is printed after the report, optional
+	summary func(report cleaner.Report)
+}
+
+var views = map[string]view{
+	"topics": {
+		title:  "\n Detecting inactive Topics...",
+		header: []string{"Topic", "Active (Last 7 Days)", "Retained", "Partitions", "Est. $/Month"},
+		row: func(f cleaner.Finding) []interface{} {
+			usage := confluent.Usage(f)
+			return []interface{}{f.Id, f.Status, outputs.Bytes(usage.RetainedBytes), usage.Partitions, f.Attributes[confluent.AttrMonthlyCost]}
+		},
+		question: "Delete all inactive topics?",
+		empty:    "No inactive topics found.",
+		summary: func(report cleaner.Report) {
+			reclaimable := confluent.Reclaimable(report)
+			fmt.Printf(" Reclaimable: %s, %d partitions. Estimated saving: $%.2f/month\n",
+				outputs.Bytes(reclaimable.RetainedBytes), reclaimable.Partitions, prices().Monthly(reclaimable))
+		},
+	},
+	"acls": {
+		title:  "\n Detecting unused ACLs...",
+		header: []string{confluent.TypeHeader, confluent.PrincipalHeader, confluent.NameHeader, confluent.PermissionHeader, confluent.OperationHeader, confluent.PatternHeader, confluent.HostHeader, confluent.StatusHeader},
+		row: func(f cleaner.Finding) []interface{} {
+			return append(aclBindingToRow(f.Resource.(kafka.ACLBinding)), f.Status)
+		},
+		question: "Delete all unused ACLs?",
+		empty:    "No inactive ACLs found.",
+	},
+	"redundant-acls": {
+		title:  "\n Detecting redundant ACLs...",
+		header: []string{confluent.TypeHeader, confluent.PrincipalHeader, confluent.NameHeader, confluent.PermissionHeader, confluent.OperationHeader, confluent.PatternHeader, confluent.HostHeader, confluent.StatusHeader, "Covered By"},
+		row: func(f cleaner.Finding) []interface{} {
+			return append(aclBindingToRow(f.Resource.(kafka.ACLBinding)), f.Status, f.Attributes[confluent.AttrCoveredBy])
+		},
+		question: "Delete the redundant ACLs?",
+		empty:    "No redundant ACLs found.",
+		summary: func(report cleaner.Report) {
+			minimal := confluent.MinimalACLs(report)
+			if len(minimal) == len(report.Findings) {
+				return
+			}
+			fmt.Printf(" Minimal equivalent set: %d of %d ACLs\n", len(minimal), len(report.Findings))
+			rows := make([][]interface{}, len(minimal))
+			for i, acl := range minimal {
+				rows[i] = aclBindingToRow(acl)
+			}
+			outputs.NewTable([]string{confluent.TypeHeader, confluent.PrincipalHeader, confluent.NameHeader, confluent.PermissionHeader, confluent.OperationHeader, confluent.PatternHeader, confluent.HostHeader}, rows)
+		},
+	},
+	"acls-import": {
+		title:  "\n ACLs to import",
+		header: []string{confluent.TypeHeader, confluent.PrincipalHeader, confluent.NameHeader, confluent.PermissionHeader, confluent.OperationHeader, confluent.PatternHeader, confluent.HostHeader, confluent.StatusHeader},
+		row: func(f cleaner.Finding) []interface{} {
+			return append(aclBindingToRow(f.Resource.(kafka.ACLBinding)), f.Status)
+		},
+		question: "Create the missing ACLs?",
+		empty:    "All the ACLs already exist.",
+	},
+	"service-accounts": {
+		title:  "\n Get Service Accounts cluster connections (Last 7 Days)",
+		header: []string{"Service Account", "Active", "Cluster API KEYs", "Cluster Role Bindings"},
+		row: func(f cleaner.Finding) []interface{} {
+			return []interface{}{f.Id, f.Status, f.Attributes[confluent.AttrApiKeys], f.Attributes[confluent.AttrRoleBindings]}
+		},
+		question: "Delete inactive Service Accounts and cluster Role bindings",
+		empty:    "No inactive service accounts found.",
+	},
+	"role-bindings": {
+		title:  "\n Detecting Role Bindings of deleted resources...",
+		header: []string{"Role Binding", "Principal", "Role", "CRN Pattern", "Status"},
+		row: func(f cleaner.Finding) []interface{} {
+			rb := f.Resource.(confluent.ConfluentCloudRoleBinding)
+			return []interface{}{f.Id, rb.Principal, rb.Role, rb.Resource, f.Status}
+		},
+		question: "Delete the role bindings of deleted resources?",
+		empty:    "No orphaned role bindings found.",
+	},
+	"api-keys": {
+		title:  "\n Get cluster API KEYs of inactive Service Accounts (Last 7 Days)",
+		header: []string{"API Key", "Owner", "Display Name", "Status"},
+		row: func(f cleaner.Finding) []interface{} {
+			return []interface{}{f.Id, f.Attributes[confluent.AttrOwnerId], f.Attributes[confluent.AttrDisplayName], f.Status}
+		},
+		question: "Delete the API KEYs of inactive Service Accounts?",
+		empty:    "No unused API KEYs found.",
+	},
+	"unquarantine": {
+		title:  "\n Quarantined Topics",
+		header: []string{"Topic", "Quarantined At", "Original Configs", "DENY ACLs"},
+		row: func(f cleaner.Finding) []interface{} {
+			record := f.Resource.(confluent.QuarantinedTopic)
+			return []interface{}{f.Id, f.Attributes[confluent.AttrQuarantinedAt], originalConfigs(confluent.QuarantineConfigs, record.Configs), len(record.DenyAcls)}
+		},
+		question: "Restore the quarantined topics?",
+		empty:    "No quarantined topics found.",
+	},
+	"unshrink": {
+		title:  "\n Shrunk Topics",
+		header: []string{"Topic", "Shrunk At", "Retained Before", "Original Configs"},
+		row: func(f cleaner.Finding) []interface{} {
+			record := f.Resource.(confluent.ShrunkTopic)
+			return []interface{}{f.Id, f.Attributes[confluent.AttrShrunkAt], outputs.Bytes(record.RetainedBytes), originalConfigs(confluent.ShrinkConfigs, record.Configs)}
+		},
+		question: "Revert the shrunk topics?",
+		empty:    "No shrunk topics found.",
+	},
+	"rightsize": {
+		title:  "\n Detecting over-partitioned Topics...",
+		header: []string{"Topic", "Status", "Partitions", "Recommended", "Bytes In/s", "Records In/s"},
+		row: func(f cleaner.Finding) []interface{} {
+			bytesIn, _ := strconv.ParseFloat(f.Attributes[confluent.AttrBytesIn], 64)
+			return []interface{}{f.Id, f.Status, f.Attributes[confluent.AttrPartitions], f.Attributes[confluent.AttrRecommendedPartitions],
+				outputs.Bytes(bytesIn), f.Attributes[confluent.AttrRecordsIn]}
+		},
+		question: "Recreate the over-partitioned topics? Stop their producers and consumers first, consumer group offsets are not kept",
+		empty:    "No over-partitioned topics found.",
+	},
+	"rightsize-rollback": {
+		title:  "\n Unfinished Topic recreations",
+		header: []string{"Topic", "Phase", "Original Partitions", "Temporary Topic"},
+		row: func(f cleaner.Finding) []interface{} {
+			return []interface{}{f.Id, f.Attributes[confluent.AttrPhase], f.Attributes[confluent.AttrPartitions], f.Attributes[confluent.AttrTemporaryTopic]}
+		},
+		question: "Roll back the recreations with the original partitions?",
+		empty:    "No unfinished recreations found.",
+	},
+	"configs": {
+		title:  "\n Checking Topic configs against the policy...",
+		header: []string{"Topic", "Config", "Value", "Policy", "Fix"},
+		row: func(f cleaner.Finding) []interface{} {
+			return []interface{}{f.Attributes[confluent.AttrTopic], f.Attributes[confluent.AttrConfig], f.Attributes[confluent.AttrValue],
+				f.Attributes[confluent.AttrPolicy], f.Attributes[confluent.AttrFix]}
+		},
+		question: "Set the topic configs back into the policy?",
+		empty:    "No topic config violations found.",
+	},
+	"connectors": {
+		title:  "\n Detecting failed Connectors...",
+		header: []string{"Connector", "Id", "Type", "State"},
+		row: func(f cleaner.Finding) []interface{} {
+			return []interface{}{f.Id, f.Attributes[confluent.AttrConnectorId], f.Attributes[confluent.AttrType], f.Status}
+		},
+		question: "Delete all failed connectors?",
+		empty:    "No failed connectors found.",
+	},
+	"schemas": {
+		title:  "\n Detecting Schema Registry subjects of deleted topics...",
+		header: []string{"Subject", "Latest Version", "Status"},
+		row: func(f cleaner.Finding) []interface{} {
+			return []interface{}{f.Id, f.Attributes[confluent.AttrVersion], f.Status}
+		},
+		question: "Soft delete all subjects of deleted topics?",
+		empty:    "No subjects of deleted topics found.",
+	},
+}
+
+// shrinkView is the topics view of --action shrink, with the storage before and after the shrink
+var shrinkView = view{
+	title:  "\n Detecting inactive Topics...",
+	header: []string{"Topic", "Active (Last 7 Days)", "Partitions", "Retained", "After Shrink (About)"},
+	row: func(f cleaner.Finding) []interface{} {
+		usage := confluent.Usage(f)
+		after := ""
+		if shrunk, err := strconv.ParseFloat(f.Attributes[confluent.AttrShrunkBytes], 64); err == nil {
+			after = outputs.Bytes(shrunk)
+		}
+		return []interface{}{f.Id, f.Status, usage.Partitions, outputs.Bytes(usage.RetainedBytes), after}
+	},
+	question: "Shrink the retention of all inactive topics?",
+	empty:    "No inactive topics to shrink found.",
+	summary: func(report cleaner.Report) {
+		before, after := 0.0, 0.0
+		for _, f := range report.Candidates() {
+			shrunk, _ := strconv.ParseFloat(f.Attributes[confluent.AttrShrunkBytes], 64)
+			before += confluent.Usage(f).RetainedBytes
+			after += shrunk
+		}
+		fmt.Printf(" Storage of the inactive topics: %s before, about %s after the shrink excluding the active segments, which are kept\n", outputs.Bytes(before), outputs.Bytes(after))
+	},
+}
+
+// originalConfigs renders the original values of the changed configs
+func originalConfigs(changed map[string]string, original map[string]string) string {
+	configs := make([]string, 0, len(changed))
+	for name := range changed {
+		value, ok := original[name]
+		if !ok {
+			value = "default"
+		}
+		configs = append(configs, name+"="+value)
+	}
+	sort.Strings(configs)
+	return strings.Join(configs, ", ")
+}
+
+// runCleaner scans, renders the report and, once confirmed, applies the plan
+func runCleaner(c cleaner.Cleaner) {
+	ctx := context.Background()
+	v := views[c.Name()]
+	if grace_period > 0 {
+		c = cleaner.WithGracePeriod(c, state.NewCandidateStore(state_file), grace_period)
+	}
+	defer finishRun(c.Name())
+
+	report := runScan(ctx, c, v)
+	plan := cleaner.NewPlan(report)
+	if pending := pendingFindings(report); pending > 0 {
+		fmt.Println(pending, "candidates pending the grace period of", grace_period)
+	}
+	if len(plan.Findings) == 0 {
+		fmt.Println(v.empty)
+		return
+	}
+	plan.Findings = selectFindings(v, plan.Findings)
+	if len(plan.Findings) == 0 {
+		return
+	}
+	notifyObservers(func(o cleaner.Observer) error { return o.Planned(plan) })
+	result, err := c.Apply(ctx, plan)
+	if err != nil {
+		fmt.Println("Error applying", c.Name(), ":", err)
+		notifyError(c.Name(), err)
+		exitRun(c.Name(), 1)
+	}
+	notifyObservers(func(o cleaner.Observer) error { return o.Applied(result) })
+	renderResult(result)
+	if len(result.Failed()) > 0 {
+		exitRun(c.Name(), 1)
+	}
+}
+
+// runScan scans and renders the report, without applying it
+func runScan(ctx context.Context, c cleaner.Cleaner, v view) cleaner.Report {
+	fmt.Println(v.title)
+	report, err := c.Scan(ctx)
+	if err != nil {
+		fmt.Println("Error scanning", c.Name(), ":", err)
+		notifyError(c.Name(), err)
+		exitRun(c.Name(), 1)
+	}
+	renderReport(v, report)
+	if v.summary != nil {
+		v.summary(report)
+	}
+	notifyObservers(func(o cleaner.Observer) error { return o.Scanned(report) })
+	return report
+}
+
+// selectFindings shows the checklist of the findings of a plan and returns the chosen ones
+func selectFindings(v view, findings []cleaner.Finding) []cleaner.Finding {
+	items := make([]commons.SelectItem, len(findings))
+	for i, f := range findings {
+		cells := v.row(f)
+		label := make([]string, len(cells))
+		for j, cell := range cells {
+			label[j] = fmt.Sprint(cell)
+		}
+		items[i] = commons.SelectItem{Id: f.Id, Label: strings.Join(label, "  ")}
+	}
+	selected := make([]cleaner.Finding, 0, len(findings))
+	for _, i := range commons.BuildSelectionPrompt(v.question, items, confirm) {
+		selected = append(selected, findings[i])
+	}
+	return selected
+}
+
+func renderReport(v view, report cleaner.Report) {
+	// Optional columns, shown when any finding has the attribute
+	columns := []struct {
+		header    string
+		attribute string
+	}{
+		{"Owner", cleaner.AttrOwner},
+		{"Expires", confluent.AttrExpires},
+		{"Pending Until", cleaner.AttrPendingUntil},
+	}
+	header := v.header[:len(v.header):len(v.header)]
+	attributes := make([]string, 0)
+	for _, c := range columns {
+		for _, f := range report.Findings {
+			if _, ok := f.Attributes[c.attribute]; ok {
+				header = append(header, c.header)
+				attributes = append(attributes, c.attribute)
+				break
+			}
+		}
+	}
+	rows := make([][]interface{}, len(report.Findings))
+	for i, f := range report.Findings {
+		rows[i] = v.row(f)
+		for _, attribute := range attributes {
+			rows[i] = append(rows[i], f.Attributes[attribute])
+		}
+	}
+	outputs.NewTable(header, rows)
+	for _, w := range report.Warnings {
+		fmt.Println("Warning:", w)
+	}
+}
+
+// finishRun pushes the metrics of a one-shot run and closes it
+func finishRun(cleanerName string) {
+	pushMetrics(cleanerName)
+	closeRun()
+}
+
+// exitRun finishes a one-shot run and exits, the deferred calls don't run on os.Exit
+func exitRun(cleanerName string, code int) {
+	finishRun(cleanerName)
+	os.Exit(code)
+}
+
+// pushMetrics pushes the metrics of a one-shot run to the Pushgateway
+func pushMetrics(cleanerName string) {
+	if run_metrics == nil || pushgateway == "" {
+		return
+	}
+	if err := run_metrics.Push(pushgateway, cleanerName, cluster); err != nil {
+		fmt.Println("Warning: pushing metrics to", pushgateway, ":", err)
+	}
+}
+
+// pendingFindings counts the candidates still within the grace period
+func pendingFindings(report cleaner.Report) int {
+	pending := 0
+	for _, f := range report.Findings {
+		if f.Attributes[cleaner.AttrPendingUntil] != "" {
+			pending++
+		}
+	}
+	return pending
+}
+
+func renderResult(result cleaner.Result) {
+	rows := make([][]interface{}, len(result.Items))
+	for i, item := range result.Items {
+		status := item.Outcome()
+		if !item.Succeeded() {
+			status = fmt.Sprintf("%s: %v", status, item.Err)
+		}
+		rows[i] = []interface{}{item.Kind, item.Id, status}
+	}
+	outputs.NewTable([]string{"Kind", "Resource", "Result"}, rows)
+	fmt.Printf(" %d succeeded, %d failed\n", len(result.Succeeded()), len(result.Failed()))
+}
+
+/** kafka.ACLBinding to row */
+func aclBindingToRow(acl kafka.ACLBinding) []interface{} {
+	return []interface{}{
+		confluent.ResourceTypeName(acl.Type),
+		acl.Principal,
+		acl.Name,
+		acl.PermissionType.String(),
+		acl.Operation.String(),
+		acl.ResourcePatternType.String(),
+		acl.Host,
+	}
+}
@@ -2,9 +2,22 @@ package cleanup
 
 import (
 	"fmt"
+	"mcolomer/cloud-keeping/pkg/audit"
+	"mcolomer/cloud-keeping/pkg/bulk"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/confluent"
+	"mcolomer/cloud-keeping/pkg/events"
+	"mcolomer/cloud-keeping/pkg/metrics"
+	"mcolomer/cloud-keeping/pkg/notify"
+	"mcolomer/cloud-keeping/pkg/state"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -16,6 +29,48 @@ var (
 	cloud_api_key      string
 	cloud_api_secret   string
 	confirm            bool
+	// Endpoints
+	confluent_endpoint  string
+	metrics_endpoint    string
+	kafka_rest_endpoint string
+	kafka_bootstrap     string
+	// Stream Catalog
+	schema_registry_endpoint   string
+	schema_registry_api_key    string
+	schema_registry_api_secret string
+	// Audit
+	audit_log string
+	// Events
+	events_topic           string
+	events_bootstrap       string
+	events_api_key         string
+	events_api_secret      string
+	events_producer_config []string
+	// Notifiers
+	notify_config string
+	// Prometheus Pushgateway of one-shot runs
+	pushgateway string
+	// Metrics of the run, nil without Pushgateway or daemon
+	run_metrics *metrics.Metrics
+	// Two-phase deletion
+	grace_period time.Duration
+	state_file   string
+	// Quarantine
+	quarantine_file string
+	shrink_file     string
+	// Cost estimates
+	price_gb_month        float64
+	price_partition_month float64
+	// Bulk deletions
+	chunk_size        int
+	concurrency       int
+	operation_timeout time.Duration
+	// Observers of the cleaners: audit log, events...
+	observers []cleaner.Observer
+	// Cleanup of the run, its Kafka clients are closed on exit
+	confluentClean *confluent.ConfluentClean
+	// Principal of the Cloud API KEY owner, empty if unknown
+	operator_principal string
 )
 
 var version = "0.0.1"
@@ -44,34 +99,182 @@ var confluentCmd = &cobra.Command{
 
 func init() {
 	viper.AutomaticEnv()
+	// Accept both --flag_name and --flag-name
+	rootCmd.SetGlobalNormalizationFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		return pflag.NormalizedName(strings.ReplaceAll(name, "-", "_"))
+	})
+	// Audit log, shared by every command
+	auditDefault := viper.GetString("AUDIT_LOG")
+	if auditDefault == "" {
+		auditDefault = audit.DefaultPath()
+	}
+	rootCmd.PersistentFlags().StringVarP(&audit_log, "audit_log", "", auditDefault, "Audit log file (JSONL) or set AUDIT_LOG environment variable")
+	viper.BindPFlag("audit_log", rootCmd.PersistentFlags().Lookup("audit_log"))
+
+	// Events
+	rootCmd.PersistentFlags().StringVarP(&events_topic, "events_topic", "", viper.GetString("EVENTS_TOPIC"), "Kafka topic to publish cleanup events (CloudEvents JSON) or set EVENTS_TOPIC environment variable")
+	viper.BindPFlag("events_topic", rootCmd.PersistentFlags().Lookup("events_topic"))
+
+	rootCmd.PersistentFlags().StringVarP(&events_bootstrap, "events_bootstrap", "", viper.GetString("EVENTS_BOOTSTRAP"), "Events Kafka bootstrap servers (default: the cleaned cluster) or set EVENTS_BOOTSTRAP environment variable")
+	viper.BindPFlag("events_bootstrap", rootCmd.PersistentFlags().Lookup("events_bootstrap"))
+
+	rootCmd.PersistentFlags().StringVarP(&events_api_key, "events_api_key", "", viper.GetString("EVENTS_API_KEY"), "Events cluster API KEY (default: the cluster API KEY) or set EVENTS_API_KEY environment variable")
+	viper.BindPFlag("events_api_key", rootCmd.PersistentFlags().Lookup("events_api_key"))
+
+	rootCmd.PersistentFlags().StringVarP(&events_api_secret, "events_api_secret", "", viper.GetString("EVENTS_API_SECRET"), "Events cluster API SECRET (default: the cluster API SECRET) or set EVENTS_API_SECRET environment variable")
+	viper.BindPFlag("events_api_secret", rootCmd.PersistentFlags().Lookup("events_api_secret"))
+
+	rootCmd.PersistentFlags().StringSliceVarP(&events_producer_config, "events_producer_config", "", nil, "Additional events producer properties, key=value")
+	viper.BindPFlag("events_producer_config", rootCmd.PersistentFlags().Lookup("events_producer_config"))
+
+	// Notifiers
+	rootCmd.PersistentFlags().StringVarP(&notify_config, "notify_config", "", viper.GetString("NOTIFY_CONFIG"), "Webhook notifiers YAML file or set NOTIFY_CONFIG environment variable")
+	viper.BindPFlag("notify_config", rootCmd.PersistentFlags().Lookup("notify_config"))
+
+	// Metrics
+	rootCmd.PersistentFlags().StringVarP(&pushgateway, "pushgateway", "", viper.GetString("PUSHGATEWAY"), "Prometheus Pushgateway URL, the metrics of a one-shot run are pushed at its end, or set PUSHGATEWAY environment variable")
+	viper.BindPFlag("pushgateway", rootCmd.PersistentFlags().Lookup("pushgateway"))
+
 	// Flags
-	confluentCmd.Flags().StringVarP(&environment, "environment", "", viper.GetString("ENVIRONMENT"), "Confluent Cloud environment Id (env-xxxxx) or set ENVIRONMENT environment variable")
-	viper.BindPFlag("environment", topicsCmd.Flags().Lookup("environment"))
+	confluentCmd.PersistentFlags().StringVarP(&environment, "environment", "", viper.GetString("ENVIRONMENT"), "Confluent Cloud environment Id (env-xxxxx) or set ENVIRONMENT environment variable")
+	viper.BindPFlag("environment", confluentCmd.PersistentFlags().Lookup("environment"))
+
+	confluentCmd.PersistentFlags().StringVarP(&cluster, "cluster", "", viper.GetString("CLUSTER"), "A Confluent Cloud cluster Id (lkc-xxxxx) or set CLUSTER environment variable")
+	viper.BindPFlag("cluster", confluentCmd.PersistentFlags().Lookup("cluster"))
 
-	confluentCmd.Flags().StringVarP(&cluster, "cluster", "", viper.GetString("CLUSTER"), "A Confluent Cloud cluster Id (lkc-xxxxx) or set CLUSTER environment variable")
-	viper.BindPFlag("cluster", topicsCmd.Flags().Lookup("cluster"))
+	confluentCmd.PersistentFlags().StringVarP(&cluster_api_key, "cluster_api_key", "", viper.GetString("CLUSTER_API_KEY"), "Cluster API KEY or set CLUSTER_API_KEY environment variable")
+	viper.BindPFlag("cluster_api_key", confluentCmd.PersistentFlags().Lookup("cluster_api_key"))
 
-	confluentCmd.Flags().StringVarP(&cluster_api_key, "cluster_api_key", "", viper.GetString("CLUSTER_API_KEY"), "Cluster API KEY or set CLUSTER_API_KEY environment variable")
-	viper.BindPFlag("cluster_api_key", topicsCmd.Flags().Lookup("cluster_api_key"))
+	confluentCmd.PersistentFlags().StringVarP(&cluster_api_secret, "cluster_api_secret", "", viper.GetString("CLUSTER_API_SECRET"), "Cluster API SECRET or set CLOUD_API_KEY environment variable")
+	viper.BindPFlag("cluster_api_secret", confluentCmd.PersistentFlags().Lookup("cluster_api_secret"))
 
-	confluentCmd.Flags().StringVarP(&cluster_api_secret, "cluster_api_secret", "", viper.GetString("CLUSTER_API_SECRET"), "Cluster API SECRET or set CLOUD_API_KEY environment variable")
-	viper.BindPFlag("cluster_api_secret", topicsCmd.Flags().Lookup("cluster_api_secret"))
+	confluentCmd.PersistentFlags().StringVarP(&cloud_api_key, "cloud_api_key", "", viper.GetString("CLOUD_API_KEY"), "Cloud API KEY with Metrics API access or set CLOUD_API_KEY environment variable")
+	viper.BindPFlag("cloud_api_key", confluentCmd.PersistentFlags().Lookup("cloud_api_key"))
 
-	confluentCmd.Flags().StringVarP(&cloud_api_key, "cloud_api_key", "", viper.GetString("CLOUD_API_KEY"), "Cloud API KEY with Metrics API access or set CLOUD_API_KEY environment variable")
-	viper.BindPFlag("cloud_api_key", topicsCmd.Flags().Lookup("cloud_api_key"))
+	confluentCmd.PersistentFlags().StringVarP(&cloud_api_secret, "cloud_api_secret", "", viper.GetString("CLOUD_API_SECRET"), "Cloud API SECRET or set CLOUD_API_SECRET environment variable")
+	viper.BindPFlag("cloud_api_secret", confluentCmd.PersistentFlags().Lookup("cloud_api_secret"))
 
-	confluentCmd.Flags().StringVarP(&cloud_api_secret, "cloud_api_secret", "", viper.GetString("CLOUD_API_SECRET"), "Cloud API SECRET or set CLOUD_API_SECRET environment variable")
-	viper.BindPFlag("cloud_api_secret", topicsCmd.Flags().Lookup("cloud_api_secret"))
+	confluentCmd.PersistentFlags().BoolVarP(&confirm, "yes", "y", false, "Confirm delete - no prompt")
+	viper.BindPFlag("yes", confluentCmd.PersistentFlags().Lookup("yes"))
 
-	confluentCmd.Flags().BoolVarP(&confirm, "yes", "y", false, "Confirm delete - no prompt")
-	viper.BindPFlag("yes", topicsCmd.Flags().Lookup("yes"))
+	// Endpoints
+	confluentCmd.PersistentFlags().StringVarP(&confluent_endpoint, "confluent_endpoint", "", viper.GetString("CONFLUENT_ENDPOINT"), "Confluent Cloud API base URL (default https://api.confluent.cloud) or set CONFLUENT_ENDPOINT environment variable")
+	viper.BindPFlag("confluent_endpoint", confluentCmd.PersistentFlags().Lookup("confluent_endpoint"))
+
+	confluentCmd.PersistentFlags().StringVarP(&metrics_endpoint, "metrics_endpoint", "", viper.GetString("METRICS_ENDPOINT"), "Confluent Cloud Metrics API base URL (default https://api.telemetry.confluent.cloud) or set METRICS_ENDPOINT environment variable")
+	viper.BindPFlag("metrics_endpoint", confluentCmd.PersistentFlags().Lookup("metrics_endpoint"))
+
+	confluentCmd.PersistentFlags().StringVarP(&kafka_rest_endpoint, "kafka_rest_endpoint", "", viper.GetString("KAFKA_REST_ENDPOINT"), "Kafka REST base URL, overrides the cluster REST endpoint, or set KAFKA_REST_ENDPOINT environment variable")
+	viper.BindPFlag("kafka_rest_endpoint", confluentCmd.PersistentFlags().Lookup("kafka_rest_endpoint"))
+
+	confluentCmd.PersistentFlags().StringVarP(&kafka_bootstrap, "kafka_bootstrap", "", viper.GetString("KAFKA_BOOTSTRAP"), "Kafka bootstrap servers, overrides the cluster bootstrap endpoint, or set KAFKA_BOOTSTRAP environment variable")
+	viper.BindPFlag("kafka_bootstrap", confluentCmd.PersistentFlags().Lookup("kafka_bootstrap"))
+
+	// Stream Catalog
+	confluentCmd.PersistentFlags().StringVarP(&schema_registry_api_key, "schema_registry_api_key", "", viper.GetString("SCHEMA_REGISTRY_API_KEY"), "Schema Registry API KEY, enables Stream Catalog tags and business metadata, or set SCHEMA_REGISTRY_API_KEY environment variable")
+	viper.BindPFlag("schema_registry_api_key", confluentCmd.PersistentFlags().Lookup("schema_registry_api_key"))
+
+	confluentCmd.PersistentFlags().StringVarP(&schema_registry_api_secret, "schema_registry_api_secret", "", viper.GetString("SCHEMA_REGISTRY_API_SECRET"), "Schema Registry API SECRET or set SCHEMA_REGISTRY_API_SECRET environment variable")
+	viper.BindPFlag("schema_registry_api_secret", confluentCmd.PersistentFlags().Lookup("schema_registry_api_secret"))
+
+	confluentCmd.PersistentFlags().StringVarP(&schema_registry_endpoint, "schema_registry_endpoint", "", viper.GetString("SCHEMA_REGISTRY_ENDPOINT"), "Schema Registry URL, overrides the environment Schema Registry endpoint, or set SCHEMA_REGISTRY_ENDPOINT environment variable")
+	viper.BindPFlag("schema_registry_endpoint", confluentCmd.PersistentFlags().Lookup("schema_registry_endpoint"))
+
+	// Two-phase deletion
+	confluentCmd.PersistentFlags().DurationVarP(&grace_period, "grace_period", "", viper.GetDuration("GRACE_PERIOD"), "Delete candidates only once detected for longer than the grace period (e.g. 168h), 0 deletes on first detection, or set GRACE_PERIOD environment variable")
+	viper.BindPFlag("grace_period", confluentCmd.PersistentFlags().Lookup("grace_period"))
+
+	stateDefault := viper.GetString("STATE_FILE")
+	if stateDefault == "" {
+		stateDefault = state.DefaultCandidatesPath()
+	}
+	confluentCmd.PersistentFlags().StringVarP(&state_file, "state_file", "", stateDefault, "Candidates state file (JSON) used with --grace_period or set STATE_FILE environment variable")
+	viper.BindPFlag("state_file", confluentCmd.PersistentFlags().Lookup("state_file"))
+
+	// Cost estimates
+	priceGBDefault := confluent.DefaultPrices.GBMonth
+	if viper.IsSet("PRICE_GB_MONTH") {
+		priceGBDefault = viper.GetFloat64("PRICE_GB_MONTH")
+	}
+	confluentCmd.PersistentFlags().Float64VarP(&price_gb_month, "price_gb_month", "", priceGBDefault, "Storage price of the cost estimates, $/GB-month, or set PRICE_GB_MONTH environment variable")
+	viper.BindPFlag("price_gb_month", confluentCmd.PersistentFlags().Lookup("price_gb_month"))
+
+	confluentCmd.PersistentFlags().Float64VarP(&price_partition_month, "price_partition_month", "", viper.GetFloat64("PRICE_PARTITION_MONTH"), "Partition price of the cost estimates, $/partition-month, or set PRICE_PARTITION_MONTH environment variable")
+	viper.BindPFlag("price_partition_month", confluentCmd.PersistentFlags().Lookup("price_partition_month"))
+
+	// Quarantine
+	quarantineDefault := viper.GetString("QUARANTINE_FILE")
+	if quarantineDefault == "" {
+		quarantineDefault = confluent.DefaultQuarantinePath()
+	}
+	confluentCmd.PersistentFlags().StringVarP(&quarantine_file, "quarantine_file", "", quarantineDefault, "Quarantined topics state file (JSON) or set QUARANTINE_FILE environment variable")
+	viper.BindPFlag("quarantine_file", confluentCmd.PersistentFlags().Lookup("quarantine_file"))
+
+	// Shrink
+	shrinkDefault := viper.GetString("SHRINK_FILE")
+	if shrinkDefault == "" {
+		shrinkDefault = confluent.DefaultShrinkPath()
+	}
+	confluentCmd.PersistentFlags().StringVarP(&shrink_file, "shrink_file", "", shrinkDefault, "Shrunk topics state file (JSON) or set SHRINK_FILE environment variable")
+	viper.BindPFlag("shrink_file", confluentCmd.PersistentFlags().Lookup("shrink_file"))
+
+	// Bulk deletions
+	chunkDefault := bulk.DefaultChunkSize
+	if viper.IsSet("CHUNK_SIZE") {
+		chunkDefault = viper.GetInt("CHUNK_SIZE")
+	}
+	confluentCmd.PersistentFlags().IntVarP(&chunk_size, "chunk_size", "", chunkDefault, "Topics or ACLs deleted per Admin request or set CHUNK_SIZE environment variable")
+	viper.BindPFlag("chunk_size", confluentCmd.PersistentFlags().Lookup("chunk_size"))
+
+	concurrencyDefault := bulk.DefaultConcurrency
+	if viper.IsSet("CONCURRENCY") {
+		concurrencyDefault = viper.GetInt("CONCURRENCY")
+	}
+	confluentCmd.PersistentFlags().IntVarP(&concurrency, "concurrency", "", concurrencyDefault, "Delete requests running at once or set CONCURRENCY environment variable")
+	viper.BindPFlag("concurrency", confluentCmd.PersistentFlags().Lookup("concurrency"))
+
+	timeoutDefault := bulk.DefaultTimeout
+	if viper.IsSet("OPERATION_TIMEOUT") {
+		timeoutDefault = viper.GetDuration("OPERATION_TIMEOUT")
+	}
+	confluentCmd.PersistentFlags().DurationVarP(&operation_timeout, "operation_timeout", "", timeoutDefault, "Timeout of every delete request or set OPERATION_TIMEOUT environment variable")
+	viper.BindPFlag("operation_timeout", confluentCmd.PersistentFlags().Lookup("operation_timeout"))
 
 	confluentCmd.AddCommand(topicsCmd)
+	confluentCmd.AddCommand(unquarantineCmd)
+	confluentCmd.AddCommand(unshrinkCmd)
 	confluentCmd.AddCommand(iamCmd)
 	confluentCmd.AddCommand(aclCmd)
+	confluentCmd.AddCommand(connectorsCmd)
+	confluentCmd.AddCommand(schemasCmd)
+	confluentCmd.AddCommand(tuiCmd)
 	rootCmd.AddCommand(confluentCmd)
 }
 
+func endpoints() confluent.Endpoints {
+	return confluent.Endpoints{
+		Cloud:          confluent_endpoint,
+		Metrics:        metrics_endpoint,
+		KafkaRest:      kafka_rest_endpoint,
+		KafkaBootstrap: kafka_bootstrap,
+		SchemaRegistry: schema_registry_endpoint,
+	}
+}
+
+// bulkOptions of the deletions, the progress bar is shown on a terminal
+func bulkOptions() bulk.Options {
+	return bulk.Options{
+		ChunkSize:   chunk_size,
+		Concurrency: concurrency,
+		Timeout:     operation_timeout,
+		Progress:    isatty.IsTerminal(os.Stdout.Fd()),
+	}
+}
+
+func prices() confluent.Prices {
+	return confluent.Prices{GBMonth: price_gb_month, PartitionMonth: price_partition_month}
+}
+
 func Validate() bool {
 	if environment == "" {
 		fmt.Println("Environment required. Please provide the environment id (env-xxxxx), using the --environment flag or the ENVIRONMENT environment variable")
@@ -100,9 +303,169 @@ func Validate() bool {
 	return true
 }
 
+// newConfluentClean validates the configuration and connects to the cluster, exits on error
+func newConfluentClean(cmd *cobra.Command) *confluent.ConfluentClean {
+	if !Validate() {
+		fmt.Println("Error validating configuration.")
+		cmd.Help()
+		os.Exit(1)
+	}
+	cflt, err := connectConfluent()
+	if err != nil {
+		fmt.Println("Error connecting to Confluent Cloud:", err)
+		os.Exit(1)
+	}
+
+	// The operator is the owner of the Cloud API KEY
+	operator, err := cflt.Operator()
+	if err != nil {
+		fmt.Println("Warning: unable to get the Cloud API KEY owner:", err)
+		operator = "api-key:" + cloud_api_key
+	} else {
+		operator_principal = "User:" + operator
+	}
+	if pushgateway != "" {
+		run_metrics = metrics.New()
+	}
+	confluentClean = cflt
+	observers, err = newObservers(cflt, cluster_api_key, cluster_api_secret, operator, "")
+	if err != nil {
+		fmt.Println("Error", err)
+		exit(1)
+	}
+	return cflt
+}
+
+// closeRun flushes and closes the observers, e.g. the events producer, and the Kafka clients of the run
+func closeRun() {
+	for _, o := range observers {
+		if c, ok := o.(interface{ Close() }); ok {
+			c.Close()
+		}
+	}
+	observers = nil
+	if confluentClean != nil {
+		confluentClean.Close()
+		confluentClean = nil
+	}
+}
+
+// exit closes the run before exiting, the deferred calls don't run on os.Exit
+func exit(code int) {
+	closeRun()
+	os.Exit(code)
+}
+
+// connectConfluent connects to the cluster of the flags
+func connectConfluent() (*confluent.ConfluentClean, error) {
+	fmt.Println("\n Validating cluster configuration. ")
+	fmt.Println("  - Cluster: ", cluster)
+	fmt.Println("  - Cluster API KEY: ", cluster_api_key)
+	cflt, err := confluent.NewConfluentClean(confluent.Options{
+		Environment:      environment,
+		Cluster:          cluster,
+		ClusterApiKey:    cluster_api_key,
+		ClusterApiSecret: cluster_api_secret,
+		CloudApiKey:      cloud_api_key,
+		CloudApiSecret:   cloud_api_secret,
+		// Stream Catalog
+		SchemaRegistryApiKey:    schema_registry_api_key,
+		SchemaRegistryApiSecret: schema_registry_api_secret,
+		Endpoints:               endpoints(),
+		Prices:                  prices(),
+		Bulk:                    bulkOptions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("  - Bootstrap: ", cflt.CloudAPI.KafkaCluster.BootstrapEndpoint)
+	if cflt.Catalog != nil {
+		fmt.Println("  - Stream Catalog: ", cflt.Catalog.Endpoint)
+	}
+	return cflt, nil
+}
+
+// newObservers builds the observers of a run: the audit log, the events publisher and the notifiers
+func newObservers(cflt *confluent.ConfluentClean, clusterKey, clusterSecret, operator, runId string) ([]cleaner.Observer, error) {
+	log := audit.NewLog(audit_log, operator)
+	log.RunId = runId
+	observers := []cleaner.Observer{log}
+	if run_metrics != nil {
+		observers = append(observers, run_metrics)
+	}
+	if events_topic != "" {
+		publisher, err := newEventsPublisher(cflt, clusterKey, clusterSecret, operator)
+		if err != nil {
+			return nil, fmt.Errorf("creating events publisher: %w", err)
+		}
+		observers = append(observers, publisher)
+	}
+	if notify_config != "" {
+		config, err := notify.LoadConfig(notify_config)
+		if err != nil {
+			return nil, fmt.Errorf("loading notifiers: %w", err)
+		}
+		notifiers, err := notify.NewNotifiers(config, operator)
+		if err != nil {
+			return nil, fmt.Errorf("creating notifiers: %w", err)
+		}
+		for _, n := range notifiers {
+			observers = append(observers, n)
+		}
+	}
+	return observers, nil
+}
+
+// newEventsPublisher builds the events producer, it defaults to the cleaned cluster
+func newEventsPublisher(cflt *confluent.ConfluentClean, clusterKey, clusterSecret, operator string) (*events.Publisher, error) {
+	bootstrap := events_bootstrap
+	if bootstrap == "" {
+		bootstrap = cflt.CloudAPI.KafkaCluster.BootstrapEndpoint
+	}
+	key, secret := events_api_key, events_api_secret
+	if key == "" {
+		key, secret = clusterKey, clusterSecret
+	}
+	config := &kafka.ConfigMap{
+		"bootstrap.servers": bootstrap,
+		"security.protocol": confluent.SASL_SSL,
+		"sasl.mechanisms":   confluent.PLAIN,
+		"sasl.username":     key,
+		"sasl.password":     secret,
+		"acks":              "all",
+	}
+	for _, property := range events_producer_config {
+		k, v, ok := strings.Cut(property, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid producer property %q, expected key=value", property)
+		}
+		config.SetKey(k, v)
+	}
+	return events.NewPublisher(events_topic, config, operator)
+}
+
+// notifyObservers notifies every observer, failures are reported as warnings
+func notifyObservers(fn func(o cleaner.Observer) error) {
+	for _, o := range observers {
+		if err := fn(o); err != nil {
+			fmt.Printf("Warning: %T: %v\n", o, err)
+		}
+	}
+}
+
+// notifyError notifies the observers interested in failures
+func notifyError(cleanerName string, err error) {
+	notifyObservers(func(o cleaner.Observer) error {
+		if e, ok := o.(cleaner.ErrorObserver); ok {
+			return e.Failed(cleanerName, err)
+		}
+		return nil
+	})
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Errorf("Error executing command: %v", err)
+		fmt.Printf("Error executing command: %v\n", err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,16 @@
+package cleanup
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var schemasCmd = &cobra.Command{
+	Use:     "schemas",
+	Aliases: []string{"subjects", "sr"},
+	Short:   "Clean Schema Registry subjects",
+	Long: ` Command to Clean the Schema Registry subjects of deleted topics: <topic>-key and <topic>-value subjects
+ whose topic doesn't exist are soft deleted. It needs the Schema Registry API KEY.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runCleaner(newConfluentClean(cmd).Schemas())
+	},
+}
@@ -0,0 +1,94 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/confluent"
+	"mcolomer/cloud-keeping/pkg/metrics"
+	"mcolomer/cloud-keeping/pkg/scheduler"
+	"mcolomer/cloud-keeping/pkg/state"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	serve_config string
+	serve_addr   string
+	history_file string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run cleanup jobs on cron schedules",
+	Long: ` Long-running daemon that runs the cleanup jobs of a YAML file on their cron schedules.
+ The file is reloaded when it changes. /healthz, /metrics, /jobs and /jobs/{name}/runs are served on --addr.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		history := state.NewStore[[]scheduler.Run](history_file)
+		candidates := state.NewCandidateStore(state_file)
+		quarantine := confluent.NewQuarantineStore(quarantine_file)
+		shrink := confluent.NewShrinkStore(shrink_file)
+		// The metrics of every run are served on /metrics
+		run_metrics = metrics.New()
+		run_metrics.Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		s := scheduler.NewScheduler(serve_config, history, candidates, quarantine, shrink,
+			func(clean *confluent.ConfluentClean, cluster scheduler.ClusterConfig, operator, runId string) ([]cleaner.Observer, error) {
+				return newObservers(clean, cluster.ApiKey, cluster.ApiSecret, operator, runId)
+			})
+		if err := s.Start(); err != nil {
+			fmt.Println("Error loading scheduler configuration:", err)
+			os.Exit(1)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			if err := s.Watch(ctx); err != nil {
+				fmt.Println("Error watching scheduler configuration:", err)
+			}
+		}()
+
+		mux := http.NewServeMux()
+		mux.Handle("/", s.Handler())
+		mux.Handle("GET /metrics", promhttp.HandlerFor(run_metrics.Registry, promhttp.HandlerOpts{}))
+		server := &http.Server{Addr: serve_addr, Handler: mux}
+		go func() {
+			<-ctx.Done()
+			server.Shutdown(context.Background())
+		}()
+		fmt.Printf("\n Cleanup scheduler listening on http://%s \n", serve_addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Println("Error running scheduler:", err)
+			os.Exit(1)
+		}
+		// Wait for the running jobs
+		<-s.Stop().Done()
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVarP(&serve_config, "config", "", viper.GetString("SERVE_CONFIG"), "Scheduler jobs YAML file or set SERVE_CONFIG environment variable")
+	viper.BindPFlag("config", serveCmd.Flags().Lookup("config"))
+
+	serveCmd.Flags().StringVarP(&serve_addr, "addr", "", "localhost:8080", "Listen address of /healthz and the jobs history")
+
+	historyDefault := viper.GetString("HISTORY_FILE")
+	if historyDefault == "" {
+		historyDefault = state.DefaultPath("runs.json")
+	}
+	serveCmd.Flags().StringVarP(&history_file, "history_file", "", historyDefault, "Runs history file (JSON) or set HISTORY_FILE environment variable")
+	viper.BindPFlag("history_file", serveCmd.Flags().Lookup("history_file"))
+
+	// Shared with the confluent commands
+	serveCmd.Flags().StringVarP(&state_file, "state_file", "", confluentCmd.PersistentFlags().Lookup("state_file").DefValue, "Candidates state file (JSON) of the jobs with a grace_period or set STATE_FILE environment variable")
+	serveCmd.Flags().StringVarP(&quarantine_file, "quarantine_file", "", confluentCmd.PersistentFlags().Lookup("quarantine_file").DefValue, "Quarantined topics state file (JSON) or set QUARANTINE_FILE environment variable")
+	serveCmd.Flags().StringVarP(&shrink_file, "shrink_file", "", confluentCmd.PersistentFlags().Lookup("shrink_file").DefValue, "Shrunk topics state file (JSON) or set SHRINK_FILE environment variable")
+
+	rootCmd.AddCommand(serveCmd)
+}
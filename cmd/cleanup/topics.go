@@ -1,25 +1,169 @@
 package cleanup
 
 import (
+	"context"
 	"fmt"
 	"mcolomer/cloud-keeping/pkg/confluent"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Actions on inactive topics
+const (
+	deleteAction     = "delete"
+	quarantineAction = "quarantine"
+	shrinkAction     = "shrink"
+)
+
+var (
+	action           string
+	admin_principals []string
+	// Rightsizing
+	partition_bytes_per_sec   float64
+	partition_records_per_sec float64
+	min_partitions            int
+	rightsize_factor          float64
+	rightsize_file            string
+	rollback                  bool
+	// Config drift
+	topic_policy string
+	fix          bool
 )
 
 var topicsCmd = &cobra.Command{
 	Use:     "topics",
 	Aliases: []string{"tpcs"},
 	Short:   "Clean Topics ",
-	Long:    ` Command to Clean Confluent Cloud Topics.`,
+	Long: ` Command to Clean Confluent Cloud Topics.
+ Inactive topics are deleted, or quarantined with --action quarantine: retention is cut and the
+ principals with ALLOW ACLs on the topic get a DENY ACL on it. Use the unquarantine command to restore them.
+ User:* ACLs and role bindings are not denied, the scan warns about the topics they keep accessible.
+ With --action shrink the retention and segment configs are lowered to reclaim the storage,
+ use the unshrink command to revert them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cflt := newConfluentClean(cmd)
+		switch action {
+		case deleteAction:
+			runCleaner(cflt.Topics())
+		case quarantineAction:
+			admins := admin_principals
+			if operator_principal != "" {
+				admins = append(admins, operator_principal)
+			}
+			v := views["topics"]
+			v.question = "Quarantine all inactive topics?"
+			views["topics"] = v
+			runCleaner(cflt.Topics().Quarantine(confluent.NewQuarantineStore(quarantine_file), admins))
+		case shrinkAction:
+			views["topics"] = shrinkView
+			runCleaner(cflt.Topics().Shrink(confluent.NewShrinkStore(shrink_file)))
+		default:
+			fmt.Println("Invalid action", action, ": use delete, quarantine or shrink")
+			exit(1)
+		}
+	},
+}
+
+var unquarantineCmd = &cobra.Command{
+	Use:   "unquarantine [topic...]",
+	Short: "Restore quarantined Topics",
+	Long:  ` Command to restore the configs and remove the DENY ACLs of quarantined topics, all the quarantined topics of the cluster if no topic is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := confluent.NewQuarantineStore(quarantine_file)
+		runCleaner(newConfluentClean(cmd).Unquarantine(store, args...))
+	},
+}
+
+var unshrinkCmd = &cobra.Command{
+	Use:   "unshrink [topic...]",
+	Short: "Revert shrunk Topics",
+	Long:  ` Command to revert the retention and segment configs of shrunk topics, all the shrunk topics of the cluster if no topic is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := confluent.NewShrinkStore(shrink_file)
+		runCleaner(newConfluentClean(cmd).Unshrink(store, args...))
+	},
+}
+
+var rightsizeCmd = &cobra.Command{
+	Use:   "rightsize [topic...]",
+	Short: "Recreate over-partitioned Topics",
+	Long: ` Command to detect topics with far more partitions than their bytes-in and records-in need (busiest day of the last 7 days).
+ Once confirmed, every topic is copied to a temporary topic, recreated with the recommended partitions and its
+ configs, and the records are copied back. Producers and consumers of the topics must be stopped, consumer group
+ offsets are not kept. An unfinished recreation is restored with its original partitions with --rollback.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := confluent.NewRightsizeStore(rightsize_file)
+		cflt := newConfluentClean(cmd)
+		if rollback {
+			runCleaner(cflt.RightsizeRollback(store, args...))
+			return
+		}
+		runCleaner(cflt.Rightsize(store, confluent.RightsizeOptions{
+			PartitionBytesPerSec:   partition_bytes_per_sec,
+			PartitionRecordsPerSec: partition_records_per_sec,
+			MinPartitions:          min_partitions,
+			Factor:                 rightsize_factor,
+		}, args...))
+	},
+}
+
+var topicConfigsCmd = &cobra.Command{
+	Use:   "configs",
+	Short: "Check Topic configs against a policy",
+	Long: ` Command to list the topic configs out of a YAML policy of required values and allowed ranges, keyed by topic pattern.
+ With --fix the configs are set back into the policy.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if !Validate() {
-			fmt.Println("Error validating configuration.")
-			cmd.Help()
+		if topic_policy == "" {
+			fmt.Println("A topic policy is required: --policy or set TOPIC_POLICY environment variable")
 			os.Exit(1)
 		}
-		cflt := confluent.NewConfluentClean(environment, cluster, cluster_api_key, cluster_api_secret, cloud_api_key, cloud_api_secret)
-		cflt.HandleInactiveTopics(confirm)
+		policy, err := confluent.LoadTopicPolicy(topic_policy)
+		if err != nil {
+			fmt.Println("Error loading topic policy:", err)
+			os.Exit(1)
+		}
+		c := newConfluentClean(cmd).TopicConfigs(policy)
+		if !fix {
+			defer finishRun(c.Name())
+			runScan(context.Background(), c, views[c.Name()])
+			return
+		}
+		runCleaner(c)
 	},
 }
+
+func init() {
+	topicsCmd.Flags().StringVarP(&action, "action", "", deleteAction, "Action on inactive topics: delete, quarantine or shrink")
+	viper.BindPFlag("action", topicsCmd.Flags().Lookup("action"))
+
+	topicsCmd.Flags().StringSliceVarP(&admin_principals, "admin_principals", "", viper.GetStringSlice("ADMIN_PRINCIPALS"), "Principals (User:sa-xxxxx) without DENY ACLs on quarantined topics, the operator and the cluster API KEY owner are always included, or set ADMIN_PRINCIPALS environment variable")
+	viper.BindPFlag("admin_principals", topicsCmd.Flags().Lookup("admin_principals"))
+}
+
+func init() {
+	defaults := confluent.DefaultRightsizeOptions
+	rightsizeCmd.Flags().Float64VarP(&partition_bytes_per_sec, "partition_bytes_per_sec", "", defaults.PartitionBytesPerSec, "Bytes-in per second a partition should handle")
+	rightsizeCmd.Flags().Float64VarP(&partition_records_per_sec, "partition_records_per_sec", "", defaults.PartitionRecordsPerSec, "Records-in per second a partition should handle")
+	rightsizeCmd.Flags().IntVarP(&min_partitions, "min_partitions", "", defaults.MinPartitions, "Minimum partitions of a recreated topic")
+	rightsizeCmd.Flags().Float64VarP(&rightsize_factor, "rightsize_factor", "", defaults.Factor, "A topic is over-partitioned when it has this factor times the recommended partitions")
+
+	rightsizeDefault := viper.GetString("RIGHTSIZE_FILE")
+	if rightsizeDefault == "" {
+		rightsizeDefault = confluent.DefaultRightsizePath()
+	}
+	rightsizeCmd.Flags().StringVarP(&rightsize_file, "rightsize_file", "", rightsizeDefault, "Topic recreations state file (JSON) or set RIGHTSIZE_FILE environment variable")
+	viper.BindPFlag("rightsize_file", rightsizeCmd.Flags().Lookup("rightsize_file"))
+
+	rightsizeCmd.Flags().BoolVarP(&rollback, "rollback", "", false, "Roll back the unfinished recreations")
+
+	topicsCmd.AddCommand(rightsizeCmd)
+
+	topicConfigsCmd.Flags().StringVarP(&topic_policy, "policy", "", viper.GetString("TOPIC_POLICY"), "Topic config policy (YAML) or set TOPIC_POLICY environment variable")
+	viper.BindPFlag("policy", topicConfigsCmd.Flags().Lookup("policy"))
+
+	topicConfigsCmd.Flags().BoolVarP(&fix, "fix", "", false, "Set the topic configs back into the policy")
+
+	topicsCmd.AddCommand(topicConfigsCmd)
+}
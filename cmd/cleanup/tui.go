@@ -0,0 +1,68 @@
+package cleanup
+
+import (
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/confluent"
+	"mcolomer/cloud-keeping/pkg/tui"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse the cluster inventory",
+	Long: ` Full-screen terminal UI to browse the topics, ACLs, service accounts, API keys and connectors of the cluster.
+ Resources are sorted, filtered and marked, and the plan of the marked resources is reviewed before it is applied.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cflt := newConfluentClean(cmd)
+		// The progress bars would break the full-screen UI
+		cflt.CloudAPI.Bulk.Progress = false
+		cleaners := []cleaner.Cleaner{cflt.Topics(), cflt.ACLs(), cflt.ServiceAccounts(), cflt.ApiKeys(), cflt.Connectors()}
+		titles := []string{"Topics", "ACLs", "Service Accounts", "API Keys", "Connectors"}
+		tabs := make([]tui.Tab, len(cleaners))
+		for i, c := range cleaners {
+			v := views[c.Name()]
+			tabs[i] = tui.Tab{Title: titles[i], Cleaner: c, Header: v.header, Row: v.row}
+		}
+		tabs[0].Details = func(f cleaner.Finding) (string, error) {
+			details, err := cflt.TopicDetails(f.Id)
+			if err != nil {
+				return "", err
+			}
+			return renderTopicDetails(details), nil
+		}
+		if err := tui.Run(tui.Options{Tabs: tabs, Observers: observers}); err != nil {
+			fmt.Println("Error running the terminal UI:", err)
+			exit(1)
+		}
+		closeRun()
+	},
+}
+
+// renderTopicDetails renders the configs, the received records and the ACLs of a topic
+func renderTopicDetails(details confluent.TopicDetails) string {
+	var b strings.Builder
+	total := 0.0
+	for _, n := range details.DailyRecords {
+		total += n
+	}
+	fmt.Fprintf(&b, "Received records (Last 7 Days): %s %.0f\n", tui.Sparkline(details.DailyRecords), total)
+	names := make([]string, 0, len(details.Configs))
+	for name := range details.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	configs := make([]string, len(names))
+	for i, name := range names {
+		configs[i] = name + "=" + details.Configs[name]
+	}
+	fmt.Fprintf(&b, "Configs: %s\n", strings.Join(configs, ", "))
+	fmt.Fprintf(&b, "ACLs: %d\n", len(details.Acls))
+	for _, acl := range details.Acls {
+		fmt.Fprintf(&b, "  %s %s %s %s %s\n", acl.Principal, acl.PermissionType, acl.Operation, acl.ResourcePatternType, acl.Name)
+	}
+	return b.String()
+}
@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Audit events
+const (
+	EventDetected     = "DETECTED"
+	EventDeleted      = "DELETED"
+	EventDeleteFailed = "DELETE_FAILED"
+	// The other events of the applied items are the outcomes of their actions, e.g. QUARANTINED or RECREATE_FAILED
+)
+
+// Entry is a line of the audit log
+type Entry struct {
+	Timestamp   time.Time    `json:"timestamp"`
+	RunId       string       `json:"run_id,omitempty"`
+	Operator    string       `json:"operator"`
+	Environment string       `json:"environment"`
+	Cluster     string       `json:"cluster"`
+	Cleaner     string       `json:"cleaner"`
+	Event       string       `json:"event"`
+	Kind        cleaner.Kind `json:"kind"`
+	Resource    string       `json:"resource"`
+	Status      string       `json:"status,omitempty"`
+	PriorState  interface{}  `json:"prior_state,omitempty"`
+	Result      string       `json:"result,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// Log is an append-only JSONL audit log
+type Log struct {
+	mu       sync.Mutex
+	Path     string
+	Operator string
+	// RunId tells apart the runs of the scheduler daemon
+	RunId string
+}
+
+func NewLog(path, operator string) *Log {
+	return &Log{Path: path, Operator: operator}
+}
+
+// DefaultPath is ~/.cleanup/audit.jsonl
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "cleanup-audit.jsonl"
+	}
+	return filepath.Join(home, ".cleanup", "audit.jsonl")
+}
+
+// Append writes the entries at the end of the log
+func (l *Log) Append(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return file.Sync()
+}
+
+// Scanned records every deletion candidate of a report
+func (l *Log) Scanned(report cleaner.Report) error {
+	entries := make([]Entry, 0)
+	now := time.Now().UTC()
+	for _, f := range report.Candidates() {
+		entry := l.entry(now, report.Environment, report.Cluster, report.Cleaner)
+		entry.Event = EventDetected
+		entry.Kind = f.Kind
+		entry.Resource = f.Id
+		entry.Status = f.Status
+		if len(f.Attributes) > 0 {
+			entry.PriorState = f.Attributes
+		}
+		entries = append(entries, entry)
+	}
+	return l.Append(entries...)
+}
+
+// Planned is not audited, only detections and deletions are
+func (l *Log) Planned(plan cleaner.Plan) error {
+	return nil
+}
+
+// Applied records the outcome of every item of a result
+func (l *Log) Applied(result cleaner.Result) error {
+	entries := make([]Entry, 0)
+	now := time.Now().UTC()
+	for _, item := range result.Items {
+		entry := l.entry(now, result.Environment, result.Cluster, result.Cleaner)
+		entry.Event = item.Outcome()
+		entry.Kind = item.Kind
+		entry.Resource = item.Id
+		entry.PriorState = item.PriorState
+		entry.Result = "OK"
+		if !item.Succeeded() {
+			entry.Result = "ERROR"
+			entry.Error = item.Err.Error()
+		}
+		entries = append(entries, entry)
+	}
+	return l.Append(entries...)
+}
+
+func (l *Log) entry(ts time.Time, environment, cluster, cleanerName string) Entry {
+	return Entry{
+		Timestamp:   ts,
+		RunId:       l.RunId,
+		Operator:    l.Operator,
+		Environment: environment,
+		Cluster:     cluster,
+		Cleaner:     cleanerName,
+	}
+}
+
+// Filter of audit entries, empty fields match everything
+type Filter struct {
+	Since    time.Time
+	Cluster  string
+	Kind     string
+	Event    string
+	Resource string
+	Operator string
+}
+
+func (f Filter) Match(e Entry) bool {
+	return (f.Since.IsZero() || !e.Timestamp.Before(f.Since)) &&
+		(f.Cluster == "" || f.Cluster == e.Cluster) &&
+		(f.Kind == "" || f.Kind == string(e.Kind)) &&
+		(f.Event == "" || f.Event == e.Event) &&
+		(f.Resource == "" || f.Resource == e.Resource) &&
+		(f.Operator == "" || f.Operator == e.Operator)
+}
+
+// Read returns the entries of the log matching the filter
+func Read(path string, filter Filter) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid audit entry %s:%d: %w", path, line, err)
+		}
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,128 @@
+// Package bulk runs an operation over many items in chunks, with concurrent workers, a timeout per operation
+// and a progress bar
+package bulk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+)
+
+// Defaults of the executor
+const (
+	DefaultChunkSize   = 100
+	DefaultConcurrency = 4
+	DefaultTimeout     = 60 * time.Second
+)
+
+// ErrNoResult is the error of the items an operation returned no result for, e.g. it failed early
+var ErrNoResult = errors.New("the operation returned no result")
+
+// Options of the executor
+type Options struct {
+	// ChunkSize is the number of items of an operation, e.g. topics of a DeleteTopics request
+	ChunkSize int
+	// Concurrency is the number of operations running at once
+	Concurrency int
+	// Timeout of every operation
+	Timeout time.Duration
+	// Progress renders a progress bar with ETA on stdout
+	Progress bool
+}
+
+// WithDefaults fills the unset options
+func (o Options) WithDefaults() Options {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultConcurrency
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	return o
+}
+
+// Run splits the items in chunks and runs op on every chunk with its own timeout.
+// op returns a result per item of the chunk, in order, and the results are returned in the order of the items.
+// The items op returned no result for get errResult(item, ErrNoResult).
+// failed reports the results counted as errors by the progress bar, optional.
+func Run[T, R any](opts Options, title string, items []T, op func(ctx context.Context, chunk []T) []R, errResult func(T, error) R, failed func(R) bool) []R {
+	opts = opts.WithDefaults()
+	results := make([]R, len(items))
+	if len(items) == 0 {
+		return results
+	}
+
+	var tracker *progress.Tracker
+	var rendered chan struct{}
+	if opts.Progress {
+		tracker, rendered = startProgress(title, len(items))
+	}
+
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.Concurrency, len(items)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range chunks {
+				end := min(start+opts.ChunkSize, len(items))
+				ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+				n := copy(results[start:end], op(ctx, items[start:end]))
+				cancel()
+				for i := start + n; i < end; i++ {
+					results[i] = errResult(items[i], ErrNoResult)
+				}
+				if tracker != nil {
+					increment(tracker, results[start:end], failed)
+				}
+			}
+		}()
+	}
+	for start := 0; start < len(items); start += opts.ChunkSize {
+		chunks <- start
+	}
+	close(chunks)
+	wg.Wait()
+
+	if tracker != nil {
+		tracker.MarkAsDone()
+		<-rendered
+	}
+	return results
+}
+
+func startProgress(title string, total int) (*progress.Tracker, chan struct{}) {
+	pw := progress.NewWriter()
+	pw.SetOutputWriter(os.Stdout)
+	pw.SetAutoStop(true)
+	pw.SetTrackerLength(30)
+	pw.SetMessageLength(30)
+	pw.SetUpdateFrequency(200 * time.Millisecond)
+	pw.Style().Visibility.ETA = true
+	pw.Style().Visibility.Value = true
+	tracker := &progress.Tracker{Message: title, Total: int64(total)}
+	pw.AppendTracker(tracker)
+	rendered := make(chan struct{})
+	go func() {
+		pw.Render()
+		close(rendered)
+	}()
+	return tracker, rendered
+}
+
+func increment[R any](tracker *progress.Tracker, results []R, failed func(R) bool) {
+	for _, r := range results {
+		if failed != nil && failed(r) {
+			tracker.IncrementWithError(1)
+		} else {
+			tracker.Increment(1)
+		}
+	}
+}
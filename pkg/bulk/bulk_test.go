@@ -0,0 +1,62 @@
+package bulk
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	items := make([]int, 250)
+	for i := range items {
+		items[i] = i
+	}
+	var mu sync.Mutex
+	chunks := make([]int, 0)
+	got := Run(Options{Concurrency: 3}, "test", items, func(ctx context.Context, chunk []int) []int {
+		mu.Lock()
+		chunks = append(chunks, len(chunk))
+		mu.Unlock()
+		doubled := make([]int, len(chunk))
+		for i, n := range chunk {
+			doubled[i] = 2 * n
+		}
+		return doubled
+	}, func(n int, err error) int {
+		t.Errorf("Run() errResult(%d, %v), every item has a result", n, err)
+		return 0
+	}, nil)
+
+	// The results are in the order of the items, whatever the order of the chunks
+	for i, n := range items {
+		if got[i] != 2*n {
+			t.Fatalf("Run()[%d] = %d, want %d", i, got[i], 2*n)
+		}
+	}
+	slices.Sort(chunks)
+	if want := []int{50, 100, 100}; !slices.Equal(chunks, want) {
+		t.Errorf("Run() chunks = %v, want %v", chunks, want)
+	}
+}
+
+func TestRunShortResults(t *testing.T) {
+	// An operation failing early returns fewer results than items, the missing ones are failures
+	type result struct {
+		id  string
+		err error
+	}
+	got := Run(Options{ChunkSize: 3}, "test", []string{"a", "b", "c", "d"}, func(ctx context.Context, chunk []string) []result {
+		results := make([]result, 0, len(chunk))
+		for _, id := range chunk[:len(chunk)-1] {
+			results = append(results, result{id: id})
+		}
+		return results
+	}, func(id string, err error) result {
+		return result{id, err}
+	}, func(r result) bool { return r.err != nil })
+	want := []result{{"a", nil}, {"b", nil}, {"c", ErrNoResult}, {"d", ErrNoResult}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Run() = %v, want %v", got, want)
+	}
+}
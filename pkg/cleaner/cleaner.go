@@ -0,0 +1,184 @@
+package cleaner
+
+import (
+	"context"
+	"time"
+)
+
+// Kind of resource handled by a cleaner
+type Kind string
+
+const (
+	KindTopic          Kind = "topic"
+	KindACL            Kind = "acl"
+	KindServiceAccount Kind = "service-account"
+	KindApiKey         Kind = "api-key"
+	KindRoleBinding    Kind = "role-binding"
+	KindConnector      Kind = "connector"
+	KindTopicConfig    Kind = "topic-config"
+	KindSubject        Kind = "subject"
+)
+
+// Cleaner detects unused resources and deletes them.
+// Scan is read only, Apply deletes the resources of a plan built from a scan report.
+type Cleaner interface {
+	Name() string
+	Scan(ctx context.Context) (Report, error)
+	Apply(ctx context.Context, plan Plan) (Result, error)
+}
+
+// Observer is notified of the reports, plans and results of the cleaners, e.g. to audit or publish them
+type Observer interface {
+	Scanned(report Report) error
+	Planned(plan Plan) error
+	Applied(result Result) error
+}
+
+// ErrorObserver is an Observer also notified when a cleaner fails
+type ErrorObserver interface {
+	Failed(cleanerName string, err error) error
+}
+
+// AttrOwner is the owner of a resource, e.g. from catalog metadata
+const AttrOwner = "owner"
+
+// Finding is a resource detected by a scan
+type Finding struct {
+	Kind Kind
+	// Id identifies the resource within its kind
+	Id     string
+	Status string
+	// Candidate is true when the resource should be deleted
+	Candidate bool
+	// Attributes holds additional details of the resource, e.g. the API keys of a service account
+	Attributes map[string]string
+	// Resource is the typed resource, e.g. kafka.ACLBinding
+	Resource interface{}
+}
+
+// Report is the result of a scan
+type Report struct {
+	Cleaner     string
+	Environment string
+	Cluster     string
+	StartedAt   time.Time
+	Duration    time.Duration
+	Findings    []Finding
+	// Warnings are the checks skipped by the scan, e.g. without the permission to list a resource
+	Warnings []string
+}
+
+// Candidates returns the findings that should be deleted
+func (r Report) Candidates() []Finding {
+	candidates := make([]Finding, 0)
+	for _, f := range r.Findings {
+		if f.Candidate {
+			candidates = append(candidates, f)
+		}
+	}
+	return candidates
+}
+
+// Plan is the list of findings to delete
+type Plan struct {
+	Cleaner     string
+	Environment string
+	Cluster     string
+	Findings    []Finding
+}
+
+// NewPlan builds a plan with every candidate of the report
+func NewPlan(report Report) Plan {
+	return Plan{
+		Cleaner:     report.Cleaner,
+		Environment: report.Environment,
+		Cluster:     report.Cluster,
+		Findings:    report.Candidates(),
+	}
+}
+
+// Actions applied to resources
+const (
+	ActionDelete     = "DELETE"
+	ActionQuarantine = "QUARANTINE"
+	ActionRestore    = "RESTORE"
+	ActionRecreate   = "RECREATE"
+	ActionAlter      = "ALTER"
+	ActionShrink     = "SHRINK"
+	ActionCreate     = "CREATE"
+)
+
+var outcomes = map[string]string{
+	ActionDelete:     "DELETED",
+	ActionQuarantine: "QUARANTINED",
+	ActionRestore:    "RESTORED",
+	ActionRecreate:   "RECREATED",
+	ActionAlter:      "ALTERED",
+	ActionShrink:     "SHRUNK",
+	ActionCreate:     "CREATED",
+}
+
+// ItemResult is the outcome of an action on a single resource
+type ItemResult struct {
+	Kind   Kind
+	Id     string
+	Action string
+	// PriorState is the state of the resource before the action, e.g. topic configs or an ACL binding
+	PriorState interface{}
+	Err        error
+}
+
+func (i ItemResult) Succeeded() bool {
+	return i.Err == nil
+}
+
+// Outcome of the item: DELETED, QUARANTINED, RESTORED, RECREATED, ALTERED, SHRUNK, CREATED or <ACTION>_FAILED
+func (i ItemResult) Outcome() string {
+	if !i.Succeeded() {
+		return i.Action + "_FAILED"
+	}
+	if outcome, ok := outcomes[i.Action]; ok {
+		return outcome
+	}
+	return i.Action
+}
+
+// Result is the outcome of applying a plan
+type Result struct {
+	Cleaner     string
+	Environment string
+	Cluster     string
+	Items       []ItemResult
+}
+
+// Succeeded returns the items applied successfully
+func (r Result) Succeeded() []ItemResult {
+	items := make([]ItemResult, 0)
+	for _, i := range r.Items {
+		if i.Succeeded() {
+			items = append(items, i)
+		}
+	}
+	return items
+}
+
+// Failed returns the items that could not be applied
+func (r Result) Failed() []ItemResult {
+	items := make([]ItemResult, 0)
+	for _, i := range r.Items {
+		if !i.Succeeded() {
+			items = append(items, i)
+		}
+	}
+	return items
+}
+
+// NewResult builds an empty result for a plan
+func NewResult(plan Plan) Result {
+	return Result{
+		Cleaner:     plan.Cleaner,
+		Environment: plan.Environment,
+		Cluster:     plan.Cluster,
+		Items:       make([]ItemResult, 0),
+	}
+}
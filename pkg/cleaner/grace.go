@@ -0,0 +1,107 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/state"
+	"strings"
+	"time"
+)
+
+// AttrPendingUntil is set on candidates still within their grace period
+const AttrPendingUntil = "pending_until"
+
+// graceCleaner marks candidates on a first scan and only keeps them as deletion candidates
+// once they have stayed candidates for longer than the grace period.
+// A candidate that is no longer detected, e.g. a topic receiving records again, is dropped.
+type graceCleaner struct {
+	Cleaner
+	store  *state.CandidateStore
+	period time.Duration
+}
+
+// Restorer is a cleaner restoring or creating resources, e.g. unquarantining topics, not delayed by a grace period
+type Restorer interface {
+	Cleaner
+	Restores()
+}
+
+// WithGracePeriod wraps a cleaner with a two-phase deletion, a Restorer is returned unwrapped
+func WithGracePeriod(c Cleaner, store *state.CandidateStore, period time.Duration) Cleaner {
+	if _, ok := c.(Restorer); ok {
+		return c
+	}
+	return &graceCleaner{Cleaner: c, store: store, period: period}
+}
+
+func candidateKey(environment, cluster, cleanerName, id string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", environment, cluster, cleanerName, id)
+}
+
+func (g *graceCleaner) Scan(ctx context.Context) (Report, error) {
+	report, err := g.Cleaner.Scan(ctx)
+	if err != nil {
+		return report, err
+	}
+	now := time.Now().UTC()
+	prefix := candidateKey(report.Environment, report.Cluster, report.Cleaner, "")
+	err = g.store.Update(func(candidates map[string]state.Candidate) error {
+		seen := make(map[string]bool, len(report.Findings))
+		for i, f := range report.Findings {
+			key := candidateKey(report.Environment, report.Cluster, report.Cleaner, f.Id)
+			if !f.Candidate {
+				continue
+			}
+			seen[key] = true
+			candidate, ok := candidates[key]
+			if !ok {
+				candidate.FirstSeen = now
+			}
+			candidate.LastSeen = now
+			candidate.Status = f.Status
+			candidates[key] = candidate
+
+			if deleteAfter := candidate.FirstSeen.Add(g.period); now.Before(deleteAfter) {
+				report.Findings[i].Candidate = false
+				if report.Findings[i].Attributes == nil {
+					report.Findings[i].Attributes = make(map[string]string)
+				}
+				report.Findings[i].Attributes[AttrPendingUntil] = deleteAfter.Format(time.RFC3339)
+			}
+		}
+		// Drop the candidates of this cleaner that are active again or gone
+		for key := range candidates {
+			if strings.HasPrefix(key, prefix) && !seen[key] {
+				delete(candidates, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("updating candidates state %s: %w", g.store.Path, err)
+	}
+	return report, nil
+}
+
+func (g *graceCleaner) Apply(ctx context.Context, plan Plan) (Result, error) {
+	result, err := g.Cleaner.Apply(ctx, plan)
+	if err != nil {
+		return result, err
+	}
+	deleted := make(map[string]bool)
+	for _, item := range result.Succeeded() {
+		deleted[item.Id] = true
+	}
+	err = g.store.Update(func(candidates map[string]state.Candidate) error {
+		for _, f := range plan.Findings {
+			if deleted[f.Id] {
+				delete(candidates, candidateKey(plan.Environment, plan.Cluster, plan.Cleaner, f.Id))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("updating candidates state %s: %w", g.store.Path, err)
+	}
+	return result, nil
+}
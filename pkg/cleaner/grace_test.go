@@ -0,0 +1,138 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"mcolomer/cloud-keeping/pkg/state"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeCleaner reports its findings and deletes the findings of a plan, except the failing ones
+type fakeCleaner struct {
+	findings []Finding
+	failing  map[string]bool
+}
+
+func (f *fakeCleaner) Name() string {
+	return "fake"
+}
+
+func (f *fakeCleaner) Scan(ctx context.Context) (Report, error) {
+	findings := make([]Finding, len(f.findings))
+	copy(findings, f.findings)
+	return Report{Cleaner: f.Name(), Environment: "env-1", Cluster: "lkc-1", Findings: findings}, nil
+}
+
+func (f *fakeCleaner) Apply(ctx context.Context, plan Plan) (Result, error) {
+	result := NewResult(plan)
+	for _, finding := range plan.Findings {
+		item := ItemResult{Id: finding.Id, Action: ActionDelete}
+		if f.failing[finding.Id] {
+			item.Err = errors.New("delete failed")
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+func candidateIds(report Report) []string {
+	ids := make([]string, 0)
+	for _, f := range report.Candidates() {
+		ids = append(ids, f.Id)
+	}
+	return ids
+}
+
+func TestGracePeriod(t *testing.T) {
+	store := state.NewCandidateStore(filepath.Join(t.TempDir(), "candidates.json"))
+	fake := &fakeCleaner{findings: []Finding{
+		{Id: "orders", Status: "ACTIVE"},
+		{Id: "legacy", Status: "INACTIVE", Candidate: true},
+	}}
+	c := WithGracePeriod(fake, store, time.Hour)
+
+	// First detection: pending until the end of the grace period
+	report, err := c.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if ids := candidateIds(report); len(ids) != 0 {
+		t.Errorf("first Scan() candidates = %v, want none", ids)
+	}
+	if report.Findings[1].Attributes[AttrPendingUntil] == "" {
+		t.Errorf("first Scan() legacy has no %s", AttrPendingUntil)
+	}
+
+	// Detected for longer than the grace period
+	err = store.Update(func(candidates map[string]state.Candidate) error {
+		key := candidateKey("env-1", "lkc-1", "fake", "legacy")
+		candidate := candidates[key]
+		candidate.FirstSeen = candidate.FirstSeen.Add(-2 * time.Hour)
+		candidates[key] = candidate
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err = c.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if ids := candidateIds(report); len(ids) != 1 || ids[0] != "legacy" {
+		t.Fatalf("Scan() after the grace period candidates = %v, want [legacy]", ids)
+	}
+
+	// A failed deletion keeps the candidate, a deleted one is dropped
+	fake.failing = map[string]bool{"legacy": true}
+	if _, err := c.Apply(context.Background(), NewPlan(report)); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if candidates, _ := store.Load(); len(candidates) != 1 {
+		t.Errorf("candidates after a failed Apply() = %v, want legacy", candidates)
+	}
+	fake.failing = nil
+	if _, err := c.Apply(context.Background(), NewPlan(report)); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if candidates, _ := store.Load(); len(candidates) != 0 {
+		t.Errorf("candidates after Apply() = %v, want none", candidates)
+	}
+}
+
+func TestGracePeriodDropsActive(t *testing.T) {
+	store := state.NewCandidateStore(filepath.Join(t.TempDir(), "candidates.json"))
+	fake := &fakeCleaner{findings: []Finding{{Id: "legacy", Status: "INACTIVE", Candidate: true}}}
+	c := WithGracePeriod(fake, store, time.Hour)
+	if _, err := c.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	// The topic receives records again
+	fake.findings = []Finding{{Id: "legacy", Status: "ACTIVE"}}
+	if _, err := c.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if candidates, _ := store.Load(); len(candidates) != 0 {
+		t.Errorf("candidates = %v, want none", candidates)
+	}
+}
+
+// fakeRestorer restores its findings, e.g. quarantined topics
+type fakeRestorer struct {
+	fakeCleaner
+}
+
+func (f *fakeRestorer) Restores() {}
+
+func TestGracePeriodRestorer(t *testing.T) {
+	store := state.NewCandidateStore(filepath.Join(t.TempDir(), "candidates.json"))
+	restorer := &fakeRestorer{fakeCleaner{findings: []Finding{{Id: "orders", Status: "QUARANTINED", Candidate: true}}}}
+	report, err := WithGracePeriod(restorer, store, time.Hour).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if ids := candidateIds(report); len(ids) != 1 {
+		t.Errorf("Scan() of a restorer candidates = %v, want [orders] on the first run", ids)
+	}
+}
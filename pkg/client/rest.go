@@ -2,12 +2,13 @@ package client
 
 import (
 	"bytes"
+	"context"
 	b64 "encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/google/martian/log"
 )
@@ -24,8 +25,38 @@ type BasicAuth struct {
 }
 
 type HTTPSClient interface {
-	Get() (interface{}, error)
-	Post(body []byte) (interface{}, error)
+	Get(out interface{}) error
+	Post(body []byte, out interface{}) error
+	Delete() error
+}
+
+// APIError is returned for any non successful HTTP response.
+// Message holds the error detail reported by the API, when the body could be parsed.
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("Rest client:: %d - %s : %s", e.StatusCode, e.Method, e.URL)
+	}
+	return fmt.Sprintf("Rest client:: %d - %s : %s : %s", e.StatusCode, e.Method, e.URL, e.Message)
+}
+
+// Error bodies returned by the Confluent Cloud APIs (cmk, iam, connect, metrics) and by the Kafka REST API
+type errorBody struct {
+	Errors []struct {
+		Status string `json:"status"`
+		Code   string `json:"code"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+	Error     string `json:"error"`
 }
 
 func NewHTTPS(url string, username string, password string) *HTTPS {
@@ -40,56 +71,114 @@ func NewHTTPS(url string, username string, password string) *HTTPS {
 	}
 }
 
-func (c *HTTPS) Get() (interface{}, error) {
-	// Get request to the endpoint
+// At returns a copy of the client targeting another endpoint,
+// so concurrent requests don't share the Endpoint field
+func (c HTTPS) At(endpoint string) *HTTPS {
+	c.Endpoint = endpoint
+	return &c
+}
+
+// Get request to the endpoint, the response body is decoded into out
+func (c *HTTPS) Get(out interface{}) error {
 	req, err := http.NewRequest(http.MethodGet, c.Endpoint, nil)
 	if err != nil {
-		log.Errorf("Error building POST: " + err.Error())
-		return nil, err
+		log.Errorf("Error building GET: " + err.Error())
+		return err
 	}
-	return c.build(req)
+	return c.build(req, out)
+}
+
+// Post request to the endpoint, the response body is decoded into out
+func (c *HTTPS) Post(requestBody []byte, out interface{}) error {
+	return c.PostContext(context.Background(), requestBody, out)
 }
 
-func (c *HTTPS) Post(requestBody []byte) (interface{}, error) {
-	req, err := http.NewRequest(http.MethodPost, c.Endpoint, bytes.NewBuffer(requestBody))
+// PostContext is a Post request canceled with the context, the response body is decoded into out
+func (c *HTTPS) PostContext(ctx context.Context, requestBody []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewBuffer(requestBody))
 	if err != nil {
 		log.Errorf("Error building POST: " + err.Error())
-		return nil, err
+		return err
 	}
-	return c.build(req)
+	return c.build(req, out)
+}
+
+func (c *HTTPS) Delete() error {
+	return c.DeleteContext(context.Background())
+}
+
+// DeleteContext is a Delete request canceled with the context, e.g. on timeout
+func (c *HTTPS) DeleteContext(ctx context.Context) error {
+	return c.DeleteContextInto(ctx, nil)
 }
 
-func (c *HTTPS) Delete() (interface{}, error) {
-	// Get request to the endpoint
-	req, err := http.NewRequest(http.MethodDelete, c.Endpoint, nil)
+// DeleteContextInto is a Delete request canceled with the context, the response body is decoded into out
+func (c *HTTPS) DeleteContextInto(ctx context.Context, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.Endpoint, nil)
 	if err != nil {
-		log.Errorf("Error building POST: " + err.Error())
-		return nil, err
+		log.Errorf("Error building DELETE: " + err.Error())
+		return err
 	}
-	return c.build(req)
+	return c.build(req, out)
 }
 
 // Build request - Client Do
-func (c *HTTPS) build(req *http.Request) (interface{}, error) {
+func (c *HTTPS) build(req *http.Request, out interface{}) error {
 	req.Header.Set("Authorization", "Basic "+c.Bearer)
 	req.Header.Set("Content-Type", "application/json;charset=utf-8")
 	res, err := c.Client.Do(req)
 	if err != nil {
 		log.Errorf("Rest client: error making http request: " + err.Error())
-		return nil, err
-	}
-	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent {
-		errorString := fmt.Sprintf("Rest client:: %d - %s : %v \n", res.StatusCode, req.Method, req.URL)
-		return nil, errors.New(errorString)
+		return err
 	}
+	defer res.Body.Close()
+
 	resBody, err := io.ReadAll(res.Body)
-	log.Infof(string(resBody))
 	if err != nil {
 		log.Errorf("HTTP client:: could not read response body: " + err.Error())
-		return nil, err
+		return err
+	}
+	log.Infof(string(resBody))
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent {
+		return &APIError{
+			StatusCode: res.StatusCode,
+			Method:     req.Method,
+			URL:        req.URL.String(),
+			Message:    errorMessage(resBody),
+		}
 	}
 
-	var result interface{}
-	json.Unmarshal([]byte(resBody), &result)
-	return result, nil
+	if out == nil || len(bytes.TrimSpace(resBody)) == 0 {
+		return nil
+	}
+	// Unknown fields are ignored, the callers validate the required fields of their models
+	if err := json.Unmarshal(resBody, out); err != nil {
+		return fmt.Errorf("Rest client:: invalid response body from %s %s: %w", req.Method, req.URL, err)
+	}
+	return nil
+}
+
+// errorMessage extracts a readable message from an API error body.
+func errorMessage(body []byte) string {
+	var e errorBody
+	if err := json.Unmarshal(body, &e); err != nil {
+		return strings.TrimSpace(string(body))
+	}
+	details := make([]string, 0, len(e.Errors))
+	for _, d := range e.Errors {
+		switch {
+		case d.Detail != "":
+			details = append(details, d.Detail)
+		case d.Title != "":
+			details = append(details, d.Title)
+		}
+	}
+	if len(details) > 0 {
+		return strings.Join(details, "; ")
+	}
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Error
 }
@@ -9,7 +9,7 @@ import (
 /** Check prefix in array of strings */
 func HasPrefix(arr []string, prefix string) bool {
 	for _, t := range arr {
-		if strings.HasPrefix(prefix, t) {
+		if strings.HasPrefix(t, prefix) {
 			return true
 		}
 	}
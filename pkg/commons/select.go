@@ -0,0 +1,222 @@
+package commons
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+)
+
+// Rows of the checklist shown at once
+const selectHeight = 15
+
+var (
+	selectTitle  = lipgloss.NewStyle().Bold(true)
+	selectCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)
+	selectHelp   = lipgloss.NewStyle().Faint(true)
+)
+
+// SelectItem is an item of a checklist
+type SelectItem struct {
+	// Id is matched by the glob filters
+	Id    string
+	Label string
+}
+
+// BuildSelectionPrompt shows a checklist of the items, all of them selected, and returns the indexes of the chosen ones.
+// Without a terminal it falls back to a y/N confirmation of every item, and confirm selects every item.
+func BuildSelectionPrompt(question string, items []SelectItem, confirm bool) []int {
+	all := make([]int, len(items))
+	for i := range items {
+		all[i] = i
+	}
+	if confirm || len(items) == 0 {
+		return all
+	}
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		if BuildConfirmationPrompt(question, false) {
+			return all
+		}
+		return nil
+	}
+	final, err := tea.NewProgram(newSelectModel(question, items)).Run()
+	if err != nil {
+		fmt.Println("Error selecting items:", err)
+		return nil
+	}
+	m := final.(selectModel)
+	if m.cancelled {
+		return nil
+	}
+	selected := make([]int, 0, len(items))
+	for i := range items {
+		if m.selected[i] {
+			selected = append(selected, i)
+		}
+	}
+	return selected
+}
+
+// MatchFilter checks an item against a filter: a glob pattern of the id when it has *, ? or [,
+// otherwise a case insensitive substring of the label
+func MatchFilter(filter string, item SelectItem) bool {
+	if filter == "" {
+		return true
+	}
+	if strings.ContainsAny(filter, "*?[") {
+		ok, _ := path.Match(filter, item.Id)
+		return ok
+	}
+	return strings.Contains(strings.ToLower(item.Label), strings.ToLower(filter))
+}
+
+// selectModel is the bubbletea model of the checklist
+type selectModel struct {
+	question  string
+	items     []SelectItem
+	selected  []bool
+	filter    string
+	filtering bool
+	// visible are the indexes of the items matching the filter
+	visible   []int
+	cursor    int
+	offset    int
+	done      bool
+	cancelled bool
+}
+
+func newSelectModel(question string, items []SelectItem) selectModel {
+	m := selectModel{question: question, items: items, selected: make([]bool, len(items))}
+	for i := range m.selected {
+		m.selected[i] = true
+	}
+	m.applyFilter()
+	return m
+}
+
+func (m selectModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m selectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if key.Type == tea.KeyCtrlC {
+		m.cancelled = true
+		return m, tea.Quit
+	}
+	if m.filtering {
+		switch key.Type {
+		case tea.KeyEnter, tea.KeyEsc:
+			m.filtering = false
+		case tea.KeyBackspace:
+			if len(m.filter) > 0 {
+				m.filter = m.filter[:len(m.filter)-1]
+				m.applyFilter()
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.filter += string(key.Runes)
+			m.applyFilter()
+		}
+		return m, nil
+	}
+	switch key.String() {
+	case "esc", "q":
+		m.cancelled = true
+		return m, tea.Quit
+	case "enter":
+		m.done = true
+		return m, tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case " ", "x":
+		if len(m.visible) > 0 {
+			i := m.visible[m.cursor]
+			m.selected[i] = !m.selected[i]
+		}
+	case "a":
+		m.selectVisible(true)
+	case "n":
+		m.selectVisible(false)
+	case "/":
+		m.filtering = true
+	}
+	return m, nil
+}
+
+func (m *selectModel) move(delta int) {
+	m.cursor = max(0, min(len(m.visible)-1, m.cursor+delta))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+selectHeight {
+		m.offset = m.cursor - selectHeight + 1
+	}
+}
+
+// selectVisible selects or deselects every item matching the filter
+func (m *selectModel) selectVisible(selected bool) {
+	for _, i := range m.visible {
+		m.selected[i] = selected
+	}
+}
+
+func (m *selectModel) applyFilter() {
+	m.visible = m.visible[:0]
+	for i, item := range m.items {
+		if MatchFilter(m.filter, item) {
+			m.visible = append(m.visible, i)
+		}
+	}
+	m.cursor, m.offset = 0, 0
+}
+
+func (m selectModel) View() string {
+	if m.done || m.cancelled {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(selectTitle.Render(m.question) + "\n")
+	if m.filtering || m.filter != "" {
+		fmt.Fprintf(&b, "Filter: %s", m.filter)
+		if m.filtering {
+			b.WriteString("█")
+		}
+		b.WriteString("\n")
+	}
+	end := min(len(m.visible), m.offset+selectHeight)
+	for row := m.offset; row < end; row++ {
+		i := m.visible[row]
+		check := "[ ]"
+		if m.selected[i] {
+			check = "[x]"
+		}
+		line := fmt.Sprintf("%s %s", check, m.items[i].Label)
+		if row == m.cursor {
+			line = selectCursor.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(m.visible) > selectHeight {
+		fmt.Fprintf(&b, "  ... %d of %d matching items\n", end-m.offset, len(m.visible))
+	}
+	count := 0
+	for _, s := range m.selected {
+		if s {
+			count++
+		}
+	}
+	fmt.Fprintf(&b, "%d of %d selected\n", count, len(m.items))
+	b.WriteString(selectHelp.Render("space: toggle • /: filter (text or glob) • a: select matching • n: deselect matching • enter: confirm • q: cancel"))
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package commons
+
+import (
+	"testing"
+)
+
+func TestMatchFilter(t *testing.T) {
+	item := SelectItem{Id: "dev-orders", Label: "dev-orders  INACTIVE  Orders of the dev team"}
+	tests := []struct {
+		name   string
+		filter string
+		want   bool
+	}{
+		{"empty", "", true},
+		{"substring of the label", "inactive", true},
+		{"glob of the id", "dev-*", true},
+		{"glob matches the id only", "*INACTIVE*", false},
+		{"invalid glob", "dev-[", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchFilter(tt.filter, item); got != tt.want {
+				t.Errorf("MatchFilter(%q) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ACL import status
+	MissingStatus = "MISSING"
+	ExistsStatus  = "EXISTS"
+
+	// Export formats
+	JSONFormat   = "json"
+	YAMLFormat   = "yaml"
+	ScriptFormat = "script"
+)
+
+// ACLExport is the file written by acls export and read by acls import
+type ACLExport struct {
+	Cluster    string     `json:"cluster" yaml:"cluster"`
+	ExportedAt time.Time  `json:"exported_at" yaml:"exported_at"`
+	Acls       []KafkaAcl `json:"acls" yaml:"acls"`
+}
+
+func NewACLExport(cluster string, acls []kafka.ACLBinding) ACLExport {
+	export := ACLExport{Cluster: cluster, ExportedAt: time.Now().UTC(), Acls: make([]KafkaAcl, len(acls))}
+	for i, acl := range acls {
+		export.Acls[i] = fromACLBinding(acl)
+	}
+	return export
+}
+
+// ValidateExportFormat checks the format of an export before anything is written
+func ValidateExportFormat(format string) error {
+	switch format {
+	case JSONFormat, YAMLFormat, ScriptFormat:
+		return nil
+	}
+	return fmt.Errorf("unknown export format %q, expected json, yaml or script", format)
+}
+
+// Write writes the export as JSON, YAML or a script of confluent kafka acl create commands
+func (e ACLExport) Write(w io.Writer, format string) error {
+	if err := ValidateExportFormat(format); err != nil {
+		return err
+	}
+	switch format {
+	case JSONFormat:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(e)
+	case YAMLFormat:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(e)
+	case ScriptFormat:
+		return e.writeScript(w)
+	}
+	return nil
+}
+
+// writeScript writes a confluent kafka acl create command per ACL, for the cluster selected with confluent kafka cluster use
+func (e ACLExport) writeScript(w io.Writer) error {
+	lines := []string{
+		"#!/bin/sh",
+		fmt.Sprintf("# ACLs of the cluster %s exported at %s", e.Cluster, e.ExportedAt.Format(time.RFC3339)),
+		"# Select the target cluster first: confluent kafka cluster use <cluster>",
+		"set -e",
+	}
+	for _, acl := range e.Acls {
+		command, err := aclCreateCommand(acl)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, command)
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// aclCreateCommand is the confluent CLI command creating an ACL. The CLI has no host flag, Confluent Cloud ACLs are for any host.
+func aclCreateCommand(acl KafkaAcl) (string, error) {
+	args := []string{"confluent", "kafka", "acl", "create", "--" + strings.ToLower(acl.Permission),
+		"--principal", shellQuote(acl.Principal),
+		"--operations", strings.ReplaceAll(strings.ToLower(acl.Operation), "_", "-")}
+	switch acl.ResourceType {
+	case "TOPIC":
+		args = append(args, "--topic", shellQuote(acl.ResourceName))
+	case "GROUP":
+		args = append(args, "--consumer-group", shellQuote(acl.ResourceName))
+	case "TRANSACTIONAL_ID":
+		args = append(args, "--transactional-id", shellQuote(acl.ResourceName))
+	case "CLUSTER", "BROKER":
+		args = append(args, "--cluster-scope")
+	default:
+		return "", fmt.Errorf("resource type %s of ACL %s/%s is not supported by the confluent CLI", acl.ResourceType, acl.Principal, acl.ResourceName)
+	}
+	if acl.PatternType == "PREFIXED" {
+		args = append(args, "--prefix")
+	}
+	return strings.Join(args, " "), nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ReadACLExport reads an export file, JSON or YAML
+func ReadACLExport(path string) (ACLExport, error) {
+	var export ACLExport
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return export, err
+	}
+	// YAML is a superset of JSON
+	if err := yaml.Unmarshal(data, &export); err != nil {
+		return export, fmt.Errorf("parsing ACL export %s: %w", path, err)
+	}
+	return export, nil
+}
+
+// Bindings parses the ACLs of the export, replacing the principals of the map, e.g. User:sa-old with User:sa-new
+func (e ACLExport) Bindings(principals map[string]string) ([]kafka.ACLBinding, error) {
+	acls := make([]kafka.ACLBinding, 0, len(e.Acls))
+	for _, row := range e.Acls {
+		if principal, ok := principals[row.Principal]; ok {
+			row.Principal = principal
+		}
+		acl, err := row.toACLBinding()
+		if err != nil {
+			return nil, err
+		}
+		acls = append(acls, acl)
+	}
+	return acls, nil
+}
+
+// ACLImporter creates the ACLs of an export missing in the cluster
+type ACLImporter struct {
+	clean *ConfluentClean
+	acls  []kafka.ACLBinding
+}
+
+func (c *ConfluentClean) ImportACLs(acls []kafka.ACLBinding) *ACLImporter {
+	return &ACLImporter{clean: c, acls: acls}
+}
+
+func (a *ACLImporter) Name() string {
+	return "acls-import"
+}
+
+// Restores creates the missing ACLs on the first run, whatever the grace period
+func (a *ACLImporter) Restores() {}
+
+func (a *ACLImporter) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := a.clean.newReport(a.Name())
+	report.StartedAt = time.Now()
+
+	existing, err := a.clean.CloudAPI.GetACLs()
+	if err != nil {
+		return report, err
+	}
+	ids := make(map[string]bool, len(existing))
+	for _, acl := range existing {
+		ids[aclBindingId(acl)] = true
+	}
+	for _, acl := range a.acls {
+		id := aclBindingId(acl)
+		finding := cleaner.Finding{
+			Kind:      cleaner.KindACL,
+			Id:        id,
+			Status:    MissingStatus,
+			Candidate: !ids[id],
+			Resource:  acl,
+		}
+		if ids[id] {
+			finding.Status = ExistsStatus
+		}
+		// An ACL listed twice in the export is created once
+		ids[id] = true
+		report.Findings = append(report.Findings, finding)
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func (a *ACLImporter) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	acls := make([]kafka.ACLBinding, 0, len(plan.Findings))
+	for _, f := range plan.Findings {
+		if acl, ok := f.Resource.(kafka.ACLBinding); ok {
+			acls = append(acls, acl)
+		}
+	}
+	if len(acls) == 0 {
+		return result, nil
+	}
+	for _, created := range a.clean.CloudAPI.ImportACLs(acls) {
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:   cleaner.KindACL,
+			Id:     created.Id,
+			Action: cleaner.ActionCreate,
+			Err:    created.Err,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package confluent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func TestBindings(t *testing.T) {
+	acls := []kafka.ACLBinding{
+		testACL("orders", literal, kafka.ACLOperationRead, principal("User:sa-old01")),
+		testACL("payments-", prefixed, kafka.ACLOperationWrite, principal("User:sa-other")),
+	}
+	for _, format := range []string{JSONFormat, YAMLFormat} {
+		path := filepath.Join(t.TempDir(), "acls."+format)
+		out, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := NewACLExport("lkc-1", acls).Write(out, format); err != nil {
+			t.Fatalf("Write(%s) error = %v", format, err)
+		}
+		out.Close()
+		export, err := ReadACLExport(path)
+		if err != nil {
+			t.Fatalf("ReadACLExport(%s) error = %v", format, err)
+		}
+		got, err := export.Bindings(map[string]string{"User:sa-old01": "User:sa-new01"})
+		if err != nil {
+			t.Fatalf("Bindings(%s) error = %v", format, err)
+		}
+		want := []kafka.ACLBinding{
+			testACL("orders", literal, kafka.ACLOperationRead, principal("User:sa-new01")),
+			acls[1],
+		}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("Bindings(%s) = %v, want %v", format, got, want)
+		}
+	}
+}
+
+func TestBindingsInvalid(t *testing.T) {
+	export := ACLExport{Acls: []KafkaAcl{{ResourceType: "TOPIC", ResourceName: "orders", PatternType: "LITERAL",
+		Principal: "User:sa-1", Host: "*", Operation: "PUBLISH", Permission: "ALLOW"}}}
+	if _, err := export.Bindings(nil); err == nil {
+		t.Error("Bindings() of an unknown operation error = nil")
+	}
+}
+
+func TestCreateACLsEachTransactionalId(t *testing.T) {
+	clean := newFakeClean(t)
+	tx := testACL("orders-tx", prefixed, kafka.ACLOperationWrite, func(acl *kafka.ACLBinding) { acl.Type = ResourceTransactionalID })
+	topic := testACL("orders", literal, kafka.ACLOperationRead)
+	// Without a broker the Admin request times out, the TRANSACTIONAL_ID ACL is created with Kafka REST
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	created := clean.CloudAPI.KafkaCluster.CreateACLsEach(ctx, []kafka.ACLBinding{topic, tx})
+	if len(created) != 2 || created[0].Id != aclBindingId(topic) || created[1].Id != aclBindingId(tx) {
+		t.Fatalf("CreateACLsEach() = %+v, want a result per ACL in order", created)
+	}
+	if created[0].Err == nil {
+		t.Errorf("CreateACLsEach() of the topic ACL error = nil, want a timeout")
+	}
+	if created[1].Err != nil {
+		t.Errorf("CreateACLsEach() of the TRANSACTIONAL_ID ACL error = %v", created[1].Err)
+	}
+	acls, err := clean.CloudAPI.GetACLs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(acls, func(acl kafka.ACLBinding) bool { return aclBindingId(acl) == aclBindingId(tx) }) {
+		t.Errorf("GetACLs() = %v, want the created TRANSACTIONAL_ID ACL", acls)
+	}
+}
@@ -0,0 +1,176 @@
+package confluent
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// ACLCleaner detects TOPIC, GROUP and TRANSACTIONAL_ID ACLs whose topics, consumer groups or transactional ids don't exist, and ACLs whose principal doesn't exist
+type ACLCleaner struct {
+	clean *ConfluentClean
+}
+
+func (a *ACLCleaner) Name() string {
+	return "acls"
+}
+
+func (a *ACLCleaner) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := a.clean.newReport(a.Name())
+	report.StartedAt = time.Now()
+
+	topicsCh := commons.AsyncCall(func() ([]string, error) {
+		return a.clean.CloudAPI.GetTopics()
+	})
+
+	aclsCh := commons.AsyncCall(func() ([]kafka.ACLBinding, error) {
+		return a.clean.CloudAPI.GetACLs()
+	})
+
+	groupsCh := commons.AsyncCall(func() ([]string, error) {
+		return a.clean.CloudAPI.GetConsumerGroups()
+	})
+
+	transactionalIdsCh := commons.AsyncCall(func() ([]string, error) {
+		return a.clean.CloudAPI.GetTransactionalIds()
+	})
+
+	principalsCh := commons.AsyncCall(func() (Principals, error) {
+		return a.clean.CloudAPI.GetPrincipals()
+	})
+
+	topics := <-topicsCh
+	acls := <-aclsCh
+	groups := <-groupsCh
+	transactionalIds := <-transactionalIdsCh
+	principals := <-principalsCh
+
+	if topics.Err != nil {
+		return report, topics.Err
+	}
+	if groups.Err != nil {
+		return report, groups.Err
+	}
+	if transactionalIds.Err != nil {
+		return report, transactionalIds.Err
+	}
+	if acls.Err != nil {
+		return report, acls.Err
+	}
+	if principals.Err != nil {
+		return report, fmt.Errorf("getting principals: %w", principals.Err)
+	}
+
+	if !principals.Result.PoolsChecked() {
+		report.Warnings = append(report.Warnings, "the Cloud API KEY can't list the identity pools of the organization, the identity pool principals are not checked")
+	}
+	unverified := make(map[kafka.ResourceType]int)
+	for _, acl := range acls.Result {
+		finding := cleaner.Finding{
+			Kind:     cleaner.KindACL,
+			Id:       aclBindingId(acl),
+			Status:   ActiveStatus,
+			Resource: acl,
+		}
+		switch acl.Type {
+		case kafka.ResourceTopic:
+			finding.Status = resourceStatus(acl, topics.Result, TopicNotFoundStatus, TopicPrefixNotFoundStatus)
+		case kafka.ResourceGroup:
+			finding.Status = listedStatus(acl, groups.Result, GroupNotFoundStatus, GroupPrefixNotFoundStatus)
+		case ResourceTransactionalID:
+			finding.Status = listedStatus(acl, transactionalIds.Result, TransactionalIdNotFoundStatus, TransactionalIdPrefixNotFoundStatus)
+		}
+		if finding.Status == UnverifiedStatus {
+			unverified[acl.Type]++
+		}
+		if id, ok := principalId(acl.Principal); ok && principals.Result.Missing(id) {
+			finding.Status = PrincipalNotFoundStatus
+		}
+		finding.Candidate = finding.Status != ActiveStatus && finding.Status != UnverifiedStatus
+		report.Findings = append(report.Findings, finding)
+	}
+	if n := unverified[kafka.ResourceGroup]; n > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("no consumer group is visible to the cluster API KEY, %d GROUP ACLs are %s: it needs DESCRIBE on the groups", n, UnverifiedStatus))
+	}
+	if n := unverified[ResourceTransactionalID]; n > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("no transactional id is visible to the cluster API KEY, %d TRANSACTIONAL_ID ACLs are %s: it needs DESCRIBE on the transactional ids", n, UnverifiedStatus))
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// listedStatus is the resourceStatus of an ACL whose resources are listed with the permissions of the cluster API KEY.
+// An empty list can be a missing DESCRIBE permission, its ACLs are UNVERIFIED instead of not found.
+func listedStatus(acl kafka.ACLBinding, names []string, notFound, prefixNotFound string) string {
+	status := resourceStatus(acl, names, notFound, prefixNotFound)
+	if len(names) == 0 && status != ActiveStatus {
+		return UnverifiedStatus
+	}
+	return status
+}
+
+// resourceStatus matches a literal or prefixed ACL with the names of the existing resources of its type
+func resourceStatus(acl kafka.ACLBinding, names []string, notFound, prefixNotFound string) string {
+	switch acl.ResourcePatternType {
+	case kafka.ResourcePatternTypeLiteral:
+		return nameStatus(acl.Name, false, names, notFound, prefixNotFound)
+	case kafka.ResourcePatternTypePrefixed:
+		return nameStatus(acl.Name, true, names, notFound, prefixNotFound)
+	}
+	return ActiveStatus
+}
+
+// nameStatus matches a name or a prefix with the names of the existing resources, the name * matches any resource
+func nameStatus(name string, prefixed bool, names []string, notFound, prefixNotFound string) string {
+	switch {
+	case prefixed && name != "" && !commons.HasPrefix(names, name):
+		return prefixNotFound
+	case !prefixed && name != "*" && !slices.Contains(names, name):
+		return notFound
+	}
+	return ActiveStatus
+}
+
+// principalId returns the id of a service account, user or identity pool principal, e.g. sa-xxxxx of User:sa-xxxxx.
+// Other principals, e.g. User:* or legacy numeric ids, can't be checked.
+func principalId(principal string) (string, bool) {
+	id, ok := strings.CutPrefix(principal, "User:")
+	if !ok {
+		return "", false
+	}
+	for _, prefix := range []string{"sa-", "u-", "pool-"} {
+		if strings.HasPrefix(id, prefix) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+func (a *ACLCleaner) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	if len(plan.Findings) == 0 {
+		return result, nil
+	}
+	acls := make([]kafka.ACLBinding, 0, len(plan.Findings))
+	for _, f := range plan.Findings {
+		if acl, ok := f.Resource.(kafka.ACLBinding); ok {
+			acls = append(acls, acl)
+		}
+	}
+	for i, deleted := range a.clean.CloudAPI.DeleteACLs(acls) {
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:       cleaner.KindACL,
+			Id:         deleted.Id,
+			Action:     cleaner.ActionDelete,
+			PriorState: fromACLBinding(acls[i]),
+			Err:        deleted.Err,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,117 @@
+package confluent
+
+import (
+	"errors"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/client"
+	"net/http"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func TestResourceStatus(t *testing.T) {
+	ids := []string{"orders-tx-1", "payments-tx"}
+	tests := []struct {
+		name    string
+		pattern kafka.ResourcePatternType
+		id      string
+		want    string
+	}{
+		{"literal found", literal, "payments-tx", ActiveStatus},
+		{"literal not found", literal, "legacy-tx", TransactionalIdNotFoundStatus},
+		{"literal *", literal, "*", ActiveStatus},
+		{"prefix found", prefixed, "orders-", ActiveStatus},
+		{"prefix not found", prefixed, "legacy-", TransactionalIdPrefixNotFoundStatus},
+		{"empty prefix", prefixed, "", ActiveStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acl := testACL(tt.id, tt.pattern, kafka.ACLOperationWrite)
+			acl.Type = ResourceTransactionalID
+			if got := resourceStatus(acl, ids, TransactionalIdNotFoundStatus, TransactionalIdPrefixNotFoundStatus); got != tt.want {
+				t.Errorf("resourceStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionalIdACL(t *testing.T) {
+	row := KafkaAcl{
+		ResourceType: "TRANSACTIONAL_ID",
+		ResourceName: "orders-tx",
+		PatternType:  "PREFIXED",
+		Principal:    "User:sa-1",
+		Host:         "*",
+		Operation:    "WRITE",
+		Permission:   "ALLOW",
+	}
+	acl, err := row.toACLBinding()
+	if err != nil {
+		t.Fatalf("toACLBinding() error = %v", err)
+	}
+	if acl.Type != ResourceTransactionalID {
+		t.Errorf("toACLBinding() type = %v, want TRANSACTIONAL_ID", acl.Type)
+	}
+	if got := fromACLBinding(acl); got != row {
+		t.Errorf("fromACLBinding() = %+v, want %+v", got, row)
+	}
+	if id, want := aclBindingId(acl), "TRANSACTIONAL_ID|PREFIXED|orders-tx|User:sa-1|*|WRITE|ALLOW"; id != want {
+		t.Errorf("aclBindingId() = %s, want %s", id, want)
+	}
+}
+
+func TestPrincipalsMissing(t *testing.T) {
+	tests := []struct {
+		name  string
+		pools bool
+		id    string
+		want  bool
+	}{
+		{"existing service account", true, "sa-1", false},
+		{"deleted service account", true, "sa-2", true},
+		{"deleted identity pool", true, "pool-2", true},
+		{"unchecked identity pool", false, "pool-2", false},
+		{"deleted service account, unchecked identity pools", false, "sa-2", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Principals{ids: map[string]bool{"sa-1": true, "pool-1": true}, pools: tt.pools}
+			if got := p.Missing(tt.id); got != tt.want {
+				t.Errorf("Missing(%s) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsForbidden(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&client.APIError{StatusCode: http.StatusUnauthorized}, true},
+		{fmt.Errorf("getting %s: %w", IDENTITY_PROVIDERS, &client.APIError{StatusCode: http.StatusForbidden}), true},
+		{&client.APIError{StatusCode: http.StatusInternalServerError}, false},
+		{errors.New("connection refused"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isForbidden(tt.err); got != tt.want {
+			t.Errorf("isForbidden(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestListedStatus(t *testing.T) {
+	acl := testACL("legacy-app", literal, kafka.ACLOperationRead, group)
+	if got := listedStatus(acl, []string{"orders-app"}, GroupNotFoundStatus, GroupPrefixNotFoundStatus); got != GroupNotFoundStatus {
+		t.Errorf("listedStatus() = %s, want %s", got, GroupNotFoundStatus)
+	}
+	// Without DESCRIBE on the groups none is listed
+	if got := listedStatus(acl, nil, GroupNotFoundStatus, GroupPrefixNotFoundStatus); got != UnverifiedStatus {
+		t.Errorf("listedStatus() of no group = %s, want %s", got, UnverifiedStatus)
+	}
+	if got := listedStatus(testACL("*", literal, kafka.ACLOperationRead, group), nil, GroupNotFoundStatus, GroupPrefixNotFoundStatus); got != ActiveStatus {
+		t.Errorf("listedStatus() of * = %s, want %s", got, ActiveStatus)
+	}
+}
@@ -0,0 +1,97 @@
+package confluent
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// The cluster API KEY of the cleanup
+	InUseStatus = "IN_USE"
+	// Finding attributes
+	AttrOwnerId     = "owner_id"
+	AttrDisplayName = "display_name"
+)
+
+// ApiKeyCleaner detects the cluster API keys of service accounts without requests in the last 7 days
+type ApiKeyCleaner struct {
+	clean *ConfluentClean
+}
+
+func (c *ConfluentClean) ApiKeys() *ApiKeyCleaner {
+	return &ApiKeyCleaner{clean: c}
+}
+
+func (a *ApiKeyCleaner) Name() string {
+	return "api-keys"
+}
+
+func (a *ApiKeyCleaner) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := a.clean.newReport(a.Name())
+	report.StartedAt = time.Now()
+
+	keysCh := commons.AsyncCall(func() ([]ApiKey, error) {
+		return a.clean.CloudAPI.ListClusterApiKeys()
+	})
+	connectionsCh := commons.AsyncCall(func() (map[string]float64, error) {
+		return a.clean.MetricsAPI.GetConnectionsByServiceAccount()
+	})
+	keys := <-keysCh
+	connections := <-connectionsCh
+	if keys.Err != nil {
+		return report, keys.Err
+	}
+	if connections.Err != nil {
+		return report, fmt.Errorf("getting service account connections: %w", connections.Err)
+	}
+
+	inUse := a.clean.CloudAPI.KafkaCluster.config["sasl.username"]
+	for _, key := range keys.Result {
+		finding := cleaner.Finding{
+			Kind:   cleaner.KindApiKey,
+			Id:     key.Id,
+			Status: ActiveStatus,
+			Attributes: map[string]string{
+				AttrOwnerId:     key.Spec.Owner.Id,
+				AttrDisplayName: key.Spec.DisplayName,
+			},
+			Resource: key,
+		}
+		// Only the keys of service accounts, user connections are not reported by the metrics
+		if strings.Contains(key.Spec.Owner.Id, SERVICE_ACCOUNT) && connections.Result[key.Spec.Owner.Id] <= 0 {
+			finding.Status = InactiveStatus
+			finding.Candidate = true
+		}
+		if key.Id == inUse {
+			finding.Status = InUseStatus
+			finding.Candidate = false
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+	sort.Slice(report.Findings, func(i, j int) bool { return report.Findings[i].Id < report.Findings[j].Id })
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func (a *ApiKeyCleaner) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	keys := make([]string, len(plan.Findings))
+	for i, f := range plan.Findings {
+		keys[i] = f.Id
+	}
+	for i, deleted := range a.clean.CloudAPI.DeleteApiKeys(keys) {
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:       cleaner.KindApiKey,
+			Id:         deleted.Id,
+			Action:     cleaner.ActionDelete,
+			PriorState: plan.Findings[i].Resource,
+			Err:        deleted.Err,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,225 @@
+package confluent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/bulk"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/client"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	//SCHEMA REGISTRY
+	SR_CLUSTERS = "/srcm/v3/clusters?environment=%s"
+	SR_SUBJECTS = "%s/subjects"
+	SR_SUBJECT  = SR_SUBJECTS + "/%s"
+	SR_LATEST   = "%s/schemas?latestOnly=true"
+	//CATALOG
+	CATALOG_TAGS              = "%s/catalog/v1/entity/type/%s/name/%s/tags"
+	CATALOG_BUSINESS_METADATA = "%s/catalog/v1/entity/type/%s/name/%s/businessmetadata"
+
+	// Catalog entity types
+	EntityTopic     = "kafka_topic"
+	EntityConnector = "cn_connector"
+	EntitySubject   = "sr_subject_version"
+
+	// Tags and attributes honoured by the cleaners
+	KeepTag          = "keep"
+	OwnerAttribute   = "owner"
+	ExpiresAttribute = "expires"
+
+	// Catalog status constants
+	ProtectedStatus = "PROTECTED"
+	ExpiredStatus   = "EXPIRED"
+
+	AttrExpires = "expires"
+
+	// catalogConcurrency is the number of entities read from the catalog at once
+	catalogConcurrency = 8
+)
+
+// CatalogMetadata are the tags and business metadata of a catalog entity
+type CatalogMetadata struct {
+	Tags    []string
+	Owner   string
+	Expires time.Time
+}
+
+// Protected resources are tagged keep
+func (m CatalogMetadata) Protected() bool {
+	return slices.Contains(m.Tags, KeepTag)
+}
+
+// CatalogClient reads Stream Catalog tags and business metadata through the Schema Registry catalog API
+type CatalogClient struct {
+	client.HTTPS
+	Endpoint string
+	// Schema Registry cluster id (lsrc-xxxxx), the prefix of the qualified names
+	ClusterID string
+}
+
+func NewCatalogClient(endpoint, cluster, sr_api_key, sr_api_secret string) *CatalogClient {
+	return &CatalogClient{
+		HTTPS:     *client.NewHTTPS(endpoint, sr_api_key, sr_api_secret),
+		Endpoint:  strings.TrimSuffix(endpoint, "/"),
+		ClusterID: cluster,
+	}
+}
+
+// QualifiedName of an entity, e.g. lsrc-xxxxx:lkc-xxxxx:topic
+func (c *CatalogClient) QualifiedName(names ...string) string {
+	return strings.Join(append([]string{c.ClusterID}, names...), ":")
+}
+
+// GetMetadata returns the tags and business metadata of an entity, an entity not in the catalog has none
+func (c *CatalogClient) GetMetadata(entityType, qualifiedName string) (CatalogMetadata, error) {
+	var metadata CatalogMetadata
+	name := url.PathEscape(qualifiedName)
+
+	businessMetadataCh := commons.AsyncCall(func() ([]CatalogBusinessMetadata, error) {
+		var businessMetadata []CatalogBusinessMetadata
+		err := c.At(fmt.Sprintf(CATALOG_BUSINESS_METADATA, c.Endpoint, entityType, name)).Get(&businessMetadata)
+		return businessMetadata, err
+	})
+	var tags []CatalogTag
+	if err := c.At(fmt.Sprintf(CATALOG_TAGS, c.Endpoint, entityType, name)).Get(&tags); err != nil && !isNotFound(err) {
+		return metadata, fmt.Errorf("getting catalog tags of %s: %w", qualifiedName, err)
+	}
+	bm := <-businessMetadataCh
+	businessMetadata := bm.Result
+	if err := bm.Err; err != nil && !isNotFound(err) {
+		return metadata, fmt.Errorf("getting catalog business metadata of %s: %w", qualifiedName, err)
+	}
+
+	// owner and expires are read from the tag attributes and the business metadata attributes
+	attributes := make([]map[string]interface{}, 0, len(tags)+len(businessMetadata))
+	for _, tag := range tags {
+		metadata.Tags = append(metadata.Tags, tag.TypeName)
+		attributes = append(attributes, tag.Attributes)
+	}
+	for _, bm := range businessMetadata {
+		attributes = append(attributes, bm.Attributes)
+	}
+	for _, attrs := range attributes {
+		if owner, ok := attrs[OwnerAttribute].(string); ok && owner != "" {
+			metadata.Owner = owner
+		}
+		if expires, ok := attrs[ExpiresAttribute].(string); ok && expires != "" {
+			t, err := parseExpires(expires)
+			if err != nil {
+				return metadata, fmt.Errorf("catalog entity %s: invalid %s %q", qualifiedName, ExpiresAttribute, expires)
+			}
+			metadata.Expires = t
+		}
+	}
+	return metadata, nil
+}
+
+// GetSubjects returns the subjects of the Schema Registry
+func (c *CatalogClient) GetSubjects() ([]string, error) {
+	var subjects []string
+	if err := c.At(fmt.Sprintf(SR_SUBJECTS, c.Endpoint)).Get(&subjects); err != nil {
+		return nil, fmt.Errorf("getting subjects: %w", err)
+	}
+	return subjects, nil
+}
+
+// GetLatestSchemas returns the latest version of the schema of every subject
+func (c *CatalogClient) GetLatestSchemas() ([]Schema, error) {
+	var schemas []Schema
+	if err := c.At(fmt.Sprintf(SR_LATEST, c.Endpoint)).Get(&schemas); err != nil {
+		return nil, fmt.Errorf("getting schemas: %w", err)
+	}
+	for _, schema := range schemas {
+		if err := schema.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return schemas, nil
+}
+
+// DeleteSubject soft deletes every version of a subject, they can still be read with deleted=true
+func (c *CatalogClient) DeleteSubject(ctx context.Context, subject string) error {
+	if err := c.At(fmt.Sprintf(SR_SUBJECT, c.Endpoint, url.PathEscape(subject))).DeleteContext(ctx); err != nil {
+		return fmt.Errorf("deleting subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// parseExpires accepts a date, 2026-12-01, or a RFC 3339 timestamp
+func parseExpires(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func isNotFound(err error) bool {
+	var apiErr *client.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// GetSchemaRegistryCluster returns the Schema Registry cluster of the environment
+func (c *ConfluentCloudClient) GetSchemaRegistryCluster() (*SchemaRegistryCluster, error) {
+	var response SchemaRegistryClusterList
+	err := c.HTTPS.At(fmt.Sprintf(c.Endpoints.Cloud+SR_CLUSTERS, c.Environment)).Get(&response)
+	if err != nil {
+		return nil, fmt.Errorf("getting schema registry cluster: %w", err)
+	}
+	if err := response.Validate(); err != nil {
+		return nil, err
+	}
+	return &response.Data[0], nil
+}
+
+// applyCatalog honours the catalog metadata of the findings: resources tagged keep are never candidates,
+// an expiry date decides the deletion and the owner is added as attribute.
+// The metadata of the findings is read concurrently.
+func (c *ConfluentClean) applyCatalog(report *cleaner.Report, entityType string, qualifiedName func(f cleaner.Finding) string) error {
+	if c.Catalog == nil {
+		return nil
+	}
+	type result struct {
+		metadata CatalogMetadata
+		err      error
+	}
+	opts := bulk.Options{ChunkSize: 1, Concurrency: catalogConcurrency}
+	results := bulk.Run(opts, "Reading the Stream Catalog", report.Findings, func(ctx context.Context, chunk []cleaner.Finding) []result {
+		metadata, err := c.Catalog.GetMetadata(entityType, qualifiedName(chunk[0]))
+		return []result{{metadata, err}}
+	}, func(f cleaner.Finding, err error) result {
+		return result{err: err}
+	}, nil)
+
+	now := time.Now()
+	for i, r := range results {
+		if r.err != nil {
+			return r.err
+		}
+		metadata := r.metadata
+		finding := &report.Findings[i]
+		if finding.Attributes == nil {
+			finding.Attributes = make(map[string]string)
+		}
+		finding.Attributes[cleaner.AttrOwner] = metadata.Owner
+		if !metadata.Expires.IsZero() {
+			finding.Attributes[AttrExpires] = metadata.Expires.Format(time.DateOnly)
+			finding.Candidate = now.After(metadata.Expires)
+			if finding.Candidate {
+				finding.Status = ExpiredStatus
+			}
+		}
+		if metadata.Protected() {
+			finding.Status = ProtectedStatus
+			finding.Candidate = false
+		}
+	}
+	return nil
+}
@@ -2,268 +2,154 @@ package confluent
 
 import (
 	"fmt"
-	"mcolomer/cloud-keeping/pkg/commons"
-	"mcolomer/cloud-keeping/pkg/outputs"
-	"os"
-	"slices"
+	"mcolomer/cloud-keeping/pkg/bulk"
+	"mcolomer/cloud-keeping/pkg/cleaner"
 	"strings"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 )
 
+// Options to connect to a Confluent Cloud cluster
+type Options struct {
+	Environment      string
+	Cluster          string
+	ClusterApiKey    string
+	ClusterApiSecret string
+	CloudApiKey      string
+	CloudApiSecret   string
+	// Schema Registry API KEY, enables the Stream Catalog tags and business metadata
+	SchemaRegistryApiKey    string
+	SchemaRegistryApiSecret string
+	Endpoints               Endpoints
+	// Prices of the cost estimates
+	Prices Prices
+	// Bulk are the options of the bulk deletions
+	Bulk bulk.Options
+}
+
 type ConfluentClean struct {
 	MetricsAPI *ConfluentCloudMetricsClient
 	CloudAPI   *ConfluentCloudClient
+	// Catalog is nil without Schema Registry credentials
+	Catalog *CatalogClient
+	Prices  Prices
 }
 
-func NewConfluentClean(environment, cluster, cluster_api_key, cluster_api_secret, cloud_api_key, cloud_api_secret string) *ConfluentClean {
-	cfltMetrics, err := NewConfluentCloudMetricsClient(cluster, cloud_api_key, cloud_api_secret)
+func NewConfluentClean(opts Options) (*ConfluentClean, error) {
+	endpoints := opts.Endpoints.WithDefaults()
+	cfltMetrics, err := NewConfluentCloudMetricsClient(endpoints.Metrics, opts.Cluster, opts.CloudApiKey, opts.CloudApiSecret)
 	if err != nil {
-		fmt.Println("Error creating Confluent Cloud Metrics Client")
-		os.Exit(1)
+		return nil, fmt.Errorf("creating Confluent Cloud Metrics client: %w", err)
 	}
-	confluentApi, err := NewConfluentCloudClient(environment, cluster, cluster_api_key, cluster_api_secret, cloud_api_key, cloud_api_secret)
+	confluentApi, err := NewConfluentCloudClient(opts.Environment, opts.Cluster, opts.ClusterApiKey, opts.ClusterApiSecret, opts.CloudApiKey, opts.CloudApiSecret, endpoints)
 	if err != nil {
-		fmt.Println("Error getting active topics")
-		os.Exit(1)
+		return nil, fmt.Errorf("creating Confluent Cloud client: %w", err)
 	}
-	return &ConfluentClean{
+	confluentApi.Bulk = opts.Bulk
+	clean := &ConfluentClean{
 		MetricsAPI: cfltMetrics,
 		CloudAPI:   confluentApi,
+		Prices:     opts.Prices,
 	}
-}
-
-// Clean Topics
-func (c *ConfluentClean) HandleInactiveTopics(confirm bool) {
-	fmt.Println("\n Detecting inactive Topics...")
-
-	activeTopicsCh := commons.AsyncCall(func() ([]string, error) {
-		return c.MetricsAPI.GetActiveTopics()
-	})
-
-	topicsCh := commons.AsyncCall(func() ([]string, error) {
-		return c.CloudAPI.GetTopics()
-	})
-
-	activeTopics := <-activeTopicsCh
-	topics := <-topicsCh
-
-	if activeTopics.Err != nil || topics.Err != nil {
-		fmt.Println("Error getting active topics")
-		os.Exit(1)
-	}
-
-	inactiveTopics := getInactiveTopics(activeTopics.Result, topics.Result)
-	if len(inactiveTopics) > 0 {
-		if commons.BuildConfirmationPrompt("Delete all inactive topics?", confirm) {
-			deleted := c.CloudAPI.DeleteTopics(inactiveTopics)
-			if len(deleted) > 0 {
-				outputs.BuildList(deleted, "Topics Deleted")
-			}
+	if opts.SchemaRegistryApiKey != "" {
+		sr, err := confluentApi.GetSchemaRegistryCluster()
+		if err != nil {
+			return nil, fmt.Errorf("creating Stream Catalog client: %w", err)
 		}
-	} else {
-		fmt.Println("No inactive topics found.")
-	}
-}
-func getInactiveTopics(activeTopics []string, topics []string) []string {
-	fmt.Printf("\n Building Topic status, in the last 7 days... \n")
-	allTopics := make([][]interface{}, len(topics))
-	var inactiveTopics []string
-	for i, topic := range topics {
-		row := make([]interface{}, 2)
-		row[0] = topic
-		if slices.Contains(activeTopics, topic) {
-			row[1] = ActiveStatus
-		} else {
-			row[1] = InactiveStatus
-			inactiveTopics = append(inactiveTopics, topic)
+		endpoint := sr.Spec.HttpEndpoint
+		if endpoints.SchemaRegistry != "" {
+			endpoint = endpoints.SchemaRegistry
 		}
-		allTopics[i] = row
+		clean.Catalog = NewCatalogClient(endpoint, sr.Id, opts.SchemaRegistryApiKey, opts.SchemaRegistryApiSecret)
 	}
-	outputs.NewTable([]string{"Topic", "Active (Last 7 Days)"}, allTopics)
-	return inactiveTopics
+	return clean, nil
 }
 
-// Clean ACLS
-func (c *ConfluentClean) HandleInactiveACLs(confirm bool) {
-	fmt.Println("Inactive ACLs")
-
-	topicsCh := commons.AsyncCall(func() ([]string, error) {
-		return c.CloudAPI.GetTopics()
-	})
-
-	aclsCh := commons.AsyncCall(func() ([]kafka.ACLBinding, error) {
-		return c.CloudAPI.GetACLs()
-	})
-
-	topics := <-topicsCh
-	acls := <-aclsCh
-
-	if topics.Err != nil || acls.Err != nil {
-		fmt.Println("Error getting resources")
-		os.Exit(1)
-	}
-
-	allACLS := make([][]interface{}, len(acls.Result))
-	inactiveACls := make([]kafka.ACLBinding, 0)
-
-	for i, acl := range acls.Result {
-		row := make([]interface{}, 8)
-		aclBindingsToRow(row, acl)
-		row[7] = ActiveStatus
-		if acl.Type == kafka.ResourceTopic {
-			if acl.ResourcePatternType == kafka.ResourcePatternTypeLiteral && !slices.Contains(topics.Result, acl.Name) {
-				row[7] = TopicNotFoundStatus
-				inactiveACls = append(inactiveACls, acl)
-			}
-			if acl.ResourcePatternType == kafka.ResourcePatternTypePrefixed && !commons.HasPrefix(topics.Result, acl.Name) {
-				row[7] = TopicPrefixNotFoundStatus
-				inactiveACls = append(inactiveACls, acl)
-			}
-		}
-		allACLS[i] = row
-	}
-	outputs.NewTable([]string{TypeHeader, PrincipalHeader, NameHeader, PermissionHeader, OperationHeader, PatternHeader, HostHeader, StatusHeader}, allACLS)
-
-	if len(inactiveACls) > 0 {
-		if commons.BuildConfirmationPrompt("Delete all unused Topic ACLs?", confirm) {
-			res, err := c.CloudAPI.DeleteACLs(inactiveACls)
-			if err != nil {
-				fmt.Println("Error deleting ACLs")
-				os.Exit(1)
-			}
-			if len(res) > 0 {
-				rows := aclBindingsToSlice(inactiveACls)
-				// Convert [][]string to [][]interface{}
-				interfaceSlice := make([][]interface{}, len(rows))
-				for i, row := range rows {
-					interfaceRow := make([]interface{}, len(row))
-					for j, val := range row {
-						interfaceRow[j] = val
-					}
-					interfaceSlice[i] = interfaceRow
-				}
-				headers := []string{TypeHeader, PrincipalHeader, NameHeader, PermissionHeader, OperationHeader, PatternHeader, HostHeader}
-				outputs.NewTable(headers, interfaceSlice)
-			}
-		}
-	} else {
-		fmt.Println("No inactive ACLs found.")
-	}
+// Cleaners
+func (c *ConfluentClean) Topics() *TopicCleaner {
+	return &TopicCleaner{clean: c}
 }
 
-// Clean Service Accounts
-func (c *ConfluentClean) HandleInactiveServiceAccounts(confirm bool) {
-	fmt.Println("\n Get Service Accounts cluster connections (Last 7 Days)")
+func (c *ConfluentClean) ACLs() *ACLCleaner {
+	return &ACLCleaner{clean: c}
+}
 
-	principalWithKeysCh := commons.AsyncCall(func() (map[string][]string, error) {
-		return c.CloudAPI.GetClusterApiKeys()
-	})
-	principalsConnectionsCh := commons.AsyncCall(func() (map[string]float64, error) {
-		return c.MetricsAPI.GetConnectionsByServiceAccount()
-	})
+func (c *ConfluentClean) ServiceAccounts() *ServiceAccountCleaner {
+	return &ServiceAccountCleaner{clean: c}
+}
 
-	principalWithKeys := <-principalWithKeysCh
-	principalsCon := <-principalsConnectionsCh
+func (c *ConfluentClean) Connectors() *ConnectorCleaner {
+	return &ConnectorCleaner{clean: c}
+}
 
-	var principalWithClusterKeys map[string][]string
-	var principalsConnections map[string]float64
+// Operator returns the owner of the Cloud API KEY, the identity running the cleanup
+func (c *ConfluentClean) Operator() (string, error) {
+	return c.CloudAPI.GetApiKeyOwner(c.CloudAPI.ApiKey)
+}
 
-	// Check the result and handle errors
-	if principalWithKeys.Err != nil {
-		fmt.Println("Error:", principalWithKeys.Err)
-	} else {
-		principalWithClusterKeys = principalWithKeys.Result
-	}
+// ClusterOperator returns the owner of the cluster API KEY, the identity of the Kafka Admin requests
+func (c *ConfluentClean) ClusterOperator() (string, error) {
+	return c.CloudAPI.GetApiKeyOwner(c.CloudAPI.KafkaCluster.ApiKey)
+}
 
-	if principalsCon.Err != nil {
-		fmt.Println("Error:", principalsCon.Err)
-	} else {
-		principalsConnections = principalsCon.Result
-	}
+var (
+	_ cleaner.Cleaner = (*TopicCleaner)(nil)
+	_ cleaner.Cleaner = (*ACLCleaner)(nil)
+	_ cleaner.Cleaner = (*ServiceAccountCleaner)(nil)
+	_ cleaner.Cleaner = (*ConnectorCleaner)(nil)
+	_ cleaner.Cleaner = (*QuarantineRestorer)(nil)
+	_ cleaner.Cleaner = (*TopicRightsizer)(nil)
+	_ cleaner.Cleaner = (*RightsizeRollback)(nil)
+	_ cleaner.Cleaner = (*TopicConfigCleaner)(nil)
+	_ cleaner.Cleaner = (*ShrinkRestorer)(nil)
+	_ cleaner.Cleaner = (*ApiKeyCleaner)(nil)
+	_ cleaner.Cleaner = (*RedundantACLCleaner)(nil)
+	_ cleaner.Cleaner = (*ACLImporter)(nil)
+	_ cleaner.Cleaner = (*RoleBindingCleaner)(nil)
+	_ cleaner.Cleaner = (*SchemaCleaner)(nil)
+
+	_ cleaner.Restorer = (*QuarantineRestorer)(nil)
+	_ cleaner.Restorer = (*ShrinkRestorer)(nil)
+	_ cleaner.Restorer = (*RightsizeRollback)(nil)
+	_ cleaner.Restorer = (*ACLImporter)(nil)
+)
 
-	inactivePrincipals := make([]string, 0)
-	inactiveRbacIds := make([]string, 0)
-	y := make([][]interface{}, 0)
-	for principal, values := range principalWithClusterKeys {
-		if strings.Contains(principal, SERVICE_ACCOUNT) {
-			row := make([]interface{}, 4)
-			conn, ok := principalsConnections[principal]
-			row[0] = principal
-			row[3] = ""
-			if ok {
-				if conn > 0 {
-					row[1] = ActiveStatus
-				}
-			} else {
-				row[1] = InactiveStatus
-				inactivePrincipals = append(inactivePrincipals, principal)
-			}
-			row[2] = values
-			rolebindingsCh := commons.AsyncCall(func() ([]ConfluentCloudRoleBinding, error) {
-				return c.CloudAPI.GetRoleBindings(principal)
-			})
-			roleBindings := <-rolebindingsCh
-			if roleBindings.Err != nil {
-				fmt.Println("Error getting role bindings")
-				os.Exit(1)
-			}
-			for _, roleBinding := range roleBindings.Result {
-				row[3] = roleBinding.Role
-				inactiveRbacIds = append(inactiveRbacIds, roleBinding.Id)
-			}
-			y = append(y, row)
-		}
-	}
+// CleanerNames are the names of the cleaners returned by AllCleaners, to validate a configuration without a cluster
+var CleanerNames = []string{"topics", "acls", "service-accounts", "connectors", "api-keys", "role-bindings", "schemas"}
 
-	outputs.NewTable([]string{"Service Account", "Active", "Cluster API KEYs", "Cluster Role Bindings"}, y)
-	if len(inactivePrincipals) > 0 {
-		if commons.BuildConfirmationPrompt("Delete inactive Service Accounts and cluster Role bindings", confirm) {
-			fmt.Println("Delete Cluster API_KEYS")
-			apikeysToDelete := make([]string, 0)
-			for _, inactive := range inactivePrincipals {
-				apikeysToDelete = append(apikeysToDelete, principalWithClusterKeys[inactive]...)
-			}
-			err := c.CloudAPI.DeleteApiKeys(apikeysToDelete)
-			if err != nil {
-				fmt.Println("Error deleting API keys")
-				os.Exit(1)
-			}
-			fmt.Println("Delete Role Bindings")
-			err = c.CloudAPI.DeleteRoleBindings(inactiveRbacIds)
-			if err != nil {
-				fmt.Println("Error deleting role bindings")
-				os.Exit(1)
-			}
-		}
-	} else {
-		fmt.Println("No inactive service accounts found.")
-	}
+// Cleaners returns every cleaner of the cluster
+func (c *ConfluentClean) Cleaners() []cleaner.Cleaner {
+	return []cleaner.Cleaner{c.Topics(), c.ACLs(), c.ServiceAccounts(), c.Connectors()}
 }
 
-// Clean Connectors
-func (c *ConfluentClean) InactiveConnectors() {
-	fmt.Println("InactiveConnectors")
+// AllCleaners returns the cleaners of the cluster and the opt-in ones: api keys, role bindings and schemas
+func (c *ConfluentClean) AllCleaners() []cleaner.Cleaner {
+	return append(c.Cleaners(), c.ApiKeys(), c.RoleBindings(), c.Schemas())
 }
 
-/** kafka.ACLBinding to slices */
-func aclBindingsToSlice(acls []kafka.ACLBinding) [][]interface{} {
-	allACLS := make([][]interface{}, len(acls))
-	for i, acl := range acls {
-		row := make([]interface{}, 7)
-		allACLS[i] = aclBindingsToRow(row, acl)
+func (c *ConfluentClean) newReport(name string) cleaner.Report {
+	return cleaner.Report{
+		Cleaner:     name,
+		Environment: c.CloudAPI.Environment,
+		Cluster:     c.CloudAPI.ClusterID,
+		Findings:    make([]cleaner.Finding, 0),
 	}
-	return allACLS
 }
 
-/** kafka.ACLBinding to []string row */
-func aclBindingsToRow(row []interface{}, acl kafka.ACLBinding) []interface{} {
-	row[0] = acl.Type.String()
-	row[1] = acl.Principal
-	row[2] = acl.Name
-	row[3] = acl.PermissionType.String()
-	row[4] = acl.Operation.String()
-	row[5] = acl.ResourcePatternType.String()
-	row[6] = acl.Host
-	return row
+/** kafka.ACLBinding to a unique id */
+func aclBindingId(acl kafka.ACLBinding) string {
+	return strings.Join([]string{
+		ResourceTypeName(acl.Type),
+		acl.ResourcePatternType.String(),
+		acl.Name,
+		acl.Principal,
+		acl.Host,
+		acl.Operation.String(),
+		acl.PermissionType.String(),
+	}, "|")
+}
+
+// Close releases the Kafka Admin client of the cluster
+func (c *ConfluentClean) Close() {
+	c.CloudAPI.KafkaCluster.AdminClient.Close()
 }
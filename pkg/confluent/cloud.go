@@ -1,8 +1,13 @@
 package confluent
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"mcolomer/cloud-keeping/pkg/bulk"
 	"mcolomer/cloud-keeping/pkg/client" // Import the package that defines the HTTPS type
+	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
@@ -13,6 +18,10 @@ type ConfluentCloudClient struct {
 	KafkaCluster ConfluentCloudCluster
 	Environment  string
 	ClusterID    string
+	ApiKey       string
+	Endpoints    Endpoints
+	// Bulk are the options of the bulk deletions
+	Bulk bulk.Options
 }
 
 type ConfluentCloudRoleBinding struct {
@@ -22,18 +31,18 @@ type ConfluentCloudRoleBinding struct {
 	Id        string
 }
 
-func NewConfluentCloudClient(environment, cluster, cluster_api_key, cluster_api_secret, cloud_api_key, cloud_api_secret string) (*ConfluentCloudClient, error) {
+func NewConfluentCloudClient(environment, cluster, cluster_api_key, cluster_api_secret, cloud_api_key, cloud_api_secret string, endpoints Endpoints) (*ConfluentCloudClient, error) {
 
-	confluentClient := &ConfluentCloudClient{}
-	cloudUrl := fmt.Sprintf(CONFLUENT_ENDPOINT+CLUSTER, cluster, environment)
+	confluentClient := &ConfluentCloudClient{Endpoints: endpoints.WithDefaults()}
+	cloudUrl := fmt.Sprintf(confluentClient.Endpoints.Cloud+CLUSTER, cluster, environment)
 
 	confluentClient.HTTPS = *client.NewHTTPS(cloudUrl, cloud_api_key, cloud_api_secret)
 	confluentClient.Environment = environment
 	confluentClient.ClusterID = cluster
+	confluentClient.ApiKey = cloud_api_key
 
 	kafkaCluster, err := confluentClient.GetKafkaCluster(cluster_api_key, cluster_api_secret)
 	if err != nil {
-		fmt.Println("Error getting cluster")
 		return nil, err
 	}
 	confluentClient.KafkaCluster = *kafkaCluster
@@ -42,37 +51,66 @@ func NewConfluentCloudClient(environment, cluster, cluster_api_key, cluster_api_
 
 // Kafka Cluster
 func (c *ConfluentCloudClient) GetKafkaCluster(cluster_api string, cluster_secret string) (*ConfluentCloudCluster, error) {
-	response, err := c.HTTPS.Get()
+	var cmkCluster CmkCluster
+	err := c.HTTPS.Get(&cmkCluster)
 	if err != nil {
-		fmt.Printf("\n Error getting cluster : %v", err)
+		return nil, fmt.Errorf("getting cluster %s: %w", c.ClusterID, err)
+	}
+	if err := cmkCluster.Validate(); err != nil {
 		return nil, err
 	}
-	responseData := response.(map[string]interface{})
-	spec := responseData["spec"].(map[string]interface{})
-	kafka_bootstrap_endpoint := spec["kafka_bootstrap_endpoint"].(string)
-	rest_endpoint := spec["http_endpoint"].(string)
-	metadata := responseData["metadata"].(map[string]interface{})
-	resource_name := metadata["resource_name"].(string)
+	resource_name := cmkCluster.Metadata.ResourceName
 	// Find the index of "/kafka="
 	kafkaIndex := strings.Index(resource_name, "/kafka=")
 	// If "/kafka=" is found, slice the string up to that index
 	if kafkaIndex != -1 {
 		resource_name = resource_name[:kafkaIndex]
 	}
-	kafkaCluster, err := NewKafkaCluster(c.ClusterID, kafka_bootstrap_endpoint, rest_endpoint, cluster_api, cluster_secret, resource_name)
-	if err != nil {
-		fmt.Println("Error getting cluster")
-		return nil, err
+	bootstrap := cmkCluster.Spec.KafkaBootstrapEndpoint
+	if c.Endpoints.KafkaBootstrap != "" {
+		bootstrap = c.Endpoints.KafkaBootstrap
 	}
-	return kafkaCluster, nil
+	restEndpoint := cmkCluster.Spec.HttpEndpoint
+	if c.Endpoints.KafkaRest != "" {
+		restEndpoint = c.Endpoints.KafkaRest
+	}
+	return NewKafkaCluster(c.ClusterID, bootstrap, restEndpoint, cluster_api, cluster_secret, resource_name)
 }
 
 // TOPICS
 func (c *ConfluentCloudClient) GetTopics() ([]string, error) {
 	return c.KafkaCluster.GetTopics()
 }
-func (c *ConfluentCloudClient) DeleteTopics(topics []string) []string {
-	return c.KafkaCluster.DeleteTopics(topics)
+func (c *ConfluentCloudClient) ListTopics() ([]KafkaTopic, error) {
+	return c.KafkaCluster.ListTopics()
+}
+func (c *ConfluentCloudClient) CreateTopic(topic string, partitions, replicationFactor int, configs map[string]string) error {
+	return c.KafkaCluster.CreateTopic(topic, partitions, replicationFactor, configs)
+}
+func (c *ConfluentCloudClient) DeleteTopic(topic string) error {
+	return c.KafkaCluster.DeleteTopic(topic)
+}
+func (c *ConfluentCloudClient) DescribeTopicConfigs(topics []string) (map[string]map[string]string, error) {
+	return c.KafkaCluster.DescribeTopicConfigs(topics)
+}
+
+// DeleteTopics deletes the topics in chunks of DeleteTopics requests
+func (c *ConfluentCloudClient) DeleteTopics(topics []string) []DeleteResult {
+	return bulk.Run(c.Bulk, "Deleting topics", topics, c.KafkaCluster.DeleteTopics, func(topic string, err error) DeleteResult {
+		return DeleteResult{Id: topic, Err: err}
+	}, failedDelete)
+}
+
+func (c *ConfluentCloudClient) AlterTopicConfigs(topic string, set map[string]string, reset []string) error {
+	return c.KafkaCluster.AlterTopicConfigs(topic, set, reset)
+}
+
+func (c *ConfluentCloudClient) GetConsumerGroups() ([]string, error) {
+	return c.KafkaCluster.GetConsumerGroups()
+}
+
+func (c *ConfluentCloudClient) GetTransactionalIds() ([]string, error) {
+	return c.KafkaCluster.GetTransactionalIds()
 }
 
 // ACLS
@@ -80,100 +118,290 @@ func (c *ConfluentCloudClient) GetACLs() ([]kafka.ACLBinding, error) {
 	return c.KafkaCluster.GetACLs()
 }
 
-func (c *ConfluentCloudClient) DeleteACLs(acls []kafka.ACLBinding) ([]kafka.DescribeACLsResult, error) {
-	return c.KafkaCluster.DeleteACLs(acls)
+// DeleteACLs deletes the ACLs in chunks of DeleteACLs requests
+func (c *ConfluentCloudClient) DeleteACLs(acls []kafka.ACLBinding) []DeleteResult {
+	return bulk.Run(c.Bulk, "Deleting ACLs", acls, c.KafkaCluster.DeleteACLs, func(acl kafka.ACLBinding, err error) DeleteResult {
+		return DeleteResult{Id: aclBindingId(acl), Err: err}
+	}, failedDelete)
+}
+
+func (c *ConfluentCloudClient) CreateACLs(acls []kafka.ACLBinding) error {
+	return c.KafkaCluster.CreateACLs(acls)
+}
+
+// ImportACLs creates the ACLs in chunks of CreateACLs requests
+func (c *ConfluentCloudClient) ImportACLs(acls []kafka.ACLBinding) []CreateResult {
+	return bulk.Run(c.Bulk, "Creating ACLs", acls, c.KafkaCluster.CreateACLsEach, func(acl kafka.ACLBinding, err error) CreateResult {
+		return CreateResult{Id: aclBindingId(acl), Err: err}
+	}, func(r CreateResult) bool { return r.Err != nil })
 }
 
 // API_KEYS
 func (c *ConfluentCloudClient) GetClusterApiKeys() (map[string][]string, error) {
-	apiKeys := make(map[string][]string)
-	c.HTTPS.Endpoint = fmt.Sprintf(CONFLUENT_ENDPOINT+CLUSTER_API_KEYS, c.ClusterID)
-	response, err := c.HTTPS.Get()
+	list, err := c.ListClusterApiKeys()
 	if err != nil {
-		fmt.Printf("\n Error getting cluster API keys: %v", err)
 		return nil, err
 	}
-	responseData := response.(map[string]interface{})
-	for _, apiKey := range responseData["data"].([]interface{}) {
-		id := apiKey.(map[string]interface{})["id"].(string)
-		spec := apiKey.(map[string]interface{})["spec"].(map[string]interface{})
-		owner := spec["owner"].(map[string]interface{})
-		ownerid := owner["id"].(string)
-		apiKeys[ownerid] = append(apiKeys[ownerid], id)
+	apiKeys := make(map[string][]string)
+	for _, apiKey := range list {
+		ownerid := apiKey.Spec.Owner.Id
+		apiKeys[ownerid] = append(apiKeys[ownerid], apiKey.Id)
 	}
 	return apiKeys, nil
 }
 
-func (c *ConfluentCloudClient) DeleteApiKeys(apiKeys []string) error {
-	fmt.Println("\n Deleting API keys: ", apiKeys)
-	c.HTTPS.Endpoint = fmt.Sprintf(CONFLUENT_ENDPOINT + API_KEYS)
-	for _, key := range apiKeys {
-		c.HTTPS.Endpoint = fmt.Sprintf("%s/%s", c.HTTPS.Endpoint, key)
-		_, err := c.HTTPS.Delete()
+// ListClusterApiKeys returns the API keys of the cluster with their owner
+func (c *ConfluentCloudClient) ListClusterApiKeys() ([]ApiKey, error) {
+	var response ApiKeyList
+	err := c.HTTPS.At(fmt.Sprintf(c.Endpoints.Cloud+CLUSTER_API_KEYS, c.ClusterID)).Get(&response)
+	if err != nil {
+		return nil, fmt.Errorf("getting cluster API keys: %w", err)
+	}
+	if err := response.Validate(); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteApiKeys deletes the API keys concurrently, with a result per key
+func (c *ConfluentCloudClient) DeleteApiKeys(apiKeys []string) []DeleteResult {
+	return c.deleteEach("Deleting API keys", apiKeys, func(ctx context.Context, key string) error {
+		if err := c.HTTPS.At(fmt.Sprintf("%s%s/%s", c.Endpoints.Cloud, API_KEYS, key)).DeleteContext(ctx); err != nil {
+			return fmt.Errorf("deleting API key %s: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// deleteEach runs a delete request per resource, the REST APIs have no bulk deletion
+func (c *ConfluentCloudClient) deleteEach(title string, ids []string, delete func(ctx context.Context, id string) error) []DeleteResult {
+	opts := c.Bulk
+	opts.ChunkSize = 1
+	return bulk.Run(opts, title, ids, func(ctx context.Context, chunk []string) []DeleteResult {
+		return []DeleteResult{{Id: chunk[0], Err: delete(ctx, chunk[0])}}
+	}, func(id string, err error) DeleteResult {
+		return DeleteResult{Id: id, Err: err}
+	}, failedDelete)
+}
+
+func failedDelete(r DeleteResult) bool {
+	return r.Err != nil
+}
+
+// GetApiKeyOwner returns the owner (user or service account) of an API key
+func (c *ConfluentCloudClient) GetApiKeyOwner(key string) (string, error) {
+	var apiKey ApiKey
+	err := c.HTTPS.At(fmt.Sprintf("%s%s/%s", c.Endpoints.Cloud, API_KEYS, key)).Get(&apiKey)
+	if err != nil {
+		return "", fmt.Errorf("getting API key %s: %w", key, err)
+	}
+	if err := apiKey.Validate(); err != nil {
+		return "", err
+	}
+	return apiKey.Spec.Owner.Id, nil
+}
+
+// IAM
+// Principals are the ids of the service accounts, users and identity pools of the organization
+type Principals struct {
+	ids map[string]bool
+	// pools is false when the Cloud API KEY can't list the identity pools
+	pools bool
+}
+
+// Missing checks if the principal id doesn't exist, the identity pools are never missing when they couldn't be listed
+func (p Principals) Missing(id string) bool {
+	if !p.pools && strings.HasPrefix(id, "pool-") {
+		return false
+	}
+	return !p.ids[id]
+}
+
+// PoolsChecked is false when the identity pools couldn't be listed
+func (p Principals) PoolsChecked() bool {
+	return p.pools
+}
+
+// GetPrincipals returns the ids of the service accounts, users and identity pools of the organization.
+// Listing the identity providers needs an organization role, without it the identity pools are not checked.
+func (c *ConfluentCloudClient) GetPrincipals() (Principals, error) {
+	principals := Principals{ids: make(map[string]bool)}
+	for _, path := range []string{SERVICE_ACCOUNTS, USERS} {
+		resources, err := c.listIam(path)
+		if err != nil {
+			return principals, err
+		}
+		for _, r := range resources {
+			principals.ids[r.Id] = true
+		}
+	}
+	pools, err := c.listIdentityPools()
+	if isForbidden(err) {
+		return principals, nil
+	}
+	if err != nil {
+		return principals, err
+	}
+	for _, pool := range pools {
+		principals.ids[pool.Id] = true
+	}
+	principals.pools = true
+	return principals, nil
+}
+
+// listIdentityPools lists the identity pools of every identity provider of the organization
+func (c *ConfluentCloudClient) listIdentityPools() ([]IamResource, error) {
+	providers, err := c.listIam(IDENTITY_PROVIDERS)
+	if err != nil {
+		return nil, err
+	}
+	pools := make([]IamResource, 0)
+	for _, provider := range providers {
+		resources, err := c.listIam(fmt.Sprintf(IDENTITY_POOLS, provider.Id))
 		if err != nil {
-			fmt.Printf("\n Error deleting API keys: %v", err)
-			return err
+			return nil, err
 		}
+		pools = append(pools, resources...)
 	}
-	return nil
+	return pools, nil
+}
+
+// isForbidden checks if the Cloud API KEY is not allowed to call the API
+func isForbidden(err error) bool {
+	var apiErr *client.APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// listIam gets every page of an IAM list
+func (c *ConfluentCloudClient) listIam(path string) ([]IamResource, error) {
+	resources := make([]IamResource, 0)
+	next := c.Endpoints.Cloud + path + "?page_size=100"
+	for next != "" {
+		var page IamList
+		if err := c.HTTPS.At(next).Get(&page); err != nil {
+			return nil, fmt.Errorf("getting %s: %w", path, err)
+		}
+		if err := page.Validate(); err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Data...)
+		next = page.Metadata.Next
+	}
+	return resources, nil
 }
 
 // RBAC
 func (c *ConfluentCloudClient) GetRoleBindings(principal string) ([]ConfluentCloudRoleBinding, error) {
-	c.HTTPS.Endpoint = fmt.Sprintf(CONFLUENT_ENDPOINT+RBAC_ENDPOINT, principal, c.KafkaCluster.CrnPatern)
-	response, err := c.HTTPS.Get()
+	var response RoleBindingList
+	err := c.HTTPS.At(fmt.Sprintf(c.Endpoints.Cloud+RBAC_ENDPOINT, principal, c.KafkaCluster.CrnPatern)).Get(&response)
 	if err != nil {
-		fmt.Printf("\n Error getting role bindings: %v", err)
+		return nil, fmt.Errorf("getting role bindings of %s: %w", principal, err)
+	}
+	if err := response.Validate(); err != nil {
 		return nil, err
 	}
-	responseData := response.(map[string]interface{})
 	roleBindings := make([]ConfluentCloudRoleBinding, 0)
-	for _, roleBinding := range responseData["data"].([]interface{}) {
-		roleBindingData := roleBinding.(map[string]interface{})
+	for _, roleBinding := range response.Data {
 		roleBindings = append(roleBindings, ConfluentCloudRoleBinding{
-			Role:      roleBindingData["role_name"].(string),
-			Resource:  roleBindingData["crn_pattern"].(string),
-			Principal: roleBindingData["principal"].(string),
-			Id:        roleBindingData["id"].(string),
+			Role:      roleBinding.RoleName,
+			Resource:  roleBinding.CrnPattern,
+			Principal: roleBinding.Principal,
+			Id:        roleBinding.Id,
 		})
 	}
 	return roleBindings, nil
 }
 
-func (c *ConfluentCloudClient) DeleteRoleBindings(roleBindings []string) error {
-	for _, roleBinding := range roleBindings {
-		c.HTTPS.Endpoint = fmt.Sprintf(CONFLUENT_ENDPOINT+"/iam/v2/role-bindings/%s", roleBinding)
-		_, err := c.HTTPS.Delete()
-		if err != nil {
-			fmt.Printf("\n Error deleting role bindings: %v", err)
-			return err
+// ListRoleBindings returns every role binding matching a CRN pattern, e.g. the bindings of an environment with <crn>/*
+func (c *ConfluentCloudClient) ListRoleBindings(crnPattern string) ([]ConfluentCloudRoleBinding, error) {
+	roleBindings := make([]ConfluentCloudRoleBinding, 0)
+	next := c.Endpoints.Cloud + fmt.Sprintf(RBAC_CRN_ENDPOINT, url.QueryEscape(crnPattern)) + "&page_size=100"
+	for next != "" {
+		var page RoleBindingList
+		if err := c.HTTPS.At(next).Get(&page); err != nil {
+			return nil, fmt.Errorf("getting role bindings of %s: %w", crnPattern, err)
+		}
+		if err := page.Validate(); err != nil {
+			return nil, err
+		}
+		for _, roleBinding := range page.Data {
+			roleBindings = append(roleBindings, ConfluentCloudRoleBinding{
+				Role:      roleBinding.RoleName,
+				Resource:  roleBinding.CrnPattern,
+				Principal: roleBinding.Principal,
+				Id:        roleBinding.Id,
+			})
+		}
+		next = page.Metadata.Next
+	}
+	return roleBindings, nil
+}
+
+// EnvironmentCrn is the CRN of the environment of the cluster
+func (c *ConfluentCloudClient) EnvironmentCrn() string {
+	crn, _, _ := strings.Cut(c.KafkaCluster.CrnPatern, "/cloud-cluster=")
+	return crn
+}
+
+// GetKafkaClusters returns the ids of the Kafka clusters of the environment
+func (c *ConfluentCloudClient) GetKafkaClusters() (map[string]bool, error) {
+	clusters := make(map[string]bool)
+	next := c.Endpoints.Cloud + fmt.Sprintf(CLUSTERS, c.Environment) + "&page_size=100"
+	for next != "" {
+		var page CmkClusterList
+		if err := c.HTTPS.At(next).Get(&page); err != nil {
+			return nil, fmt.Errorf("getting clusters of %s: %w", c.Environment, err)
 		}
+		if err := page.Validate(); err != nil {
+			return nil, err
+		}
+		for _, cluster := range page.Data {
+			clusters[cluster.Id] = true
+		}
+		next = page.Metadata.Next
 	}
-	return nil
+	return clusters, nil
+}
+
+// DeleteRoleBindings deletes the role bindings concurrently, with a result per role binding
+func (c *ConfluentCloudClient) DeleteRoleBindings(roleBindings []string) []DeleteResult {
+	return c.deleteEach("Deleting role bindings", roleBindings, func(ctx context.Context, roleBinding string) error {
+		if err := c.HTTPS.At(fmt.Sprintf("%s%s/%s", c.Endpoints.Cloud, ROLE_BINDINGS, roleBinding)).DeleteContext(ctx); err != nil {
+			return fmt.Errorf("deleting role binding %s: %w", roleBinding, err)
+		}
+		return nil
+	})
 }
 
+// CONNECTORS
 func (c *ConfluentCloudClient) GetConnectors() ([]ConfluentCloudConnector, error) {
-	c.HTTPS.Endpoint = fmt.Sprintf("https://api.confluent.cloud/connect/v1/environments/%s/clusters/%s/connectors?expand=info,status,id", c.Environment, c.ClusterID)
-	response, err := c.HTTPS.Get()
+	var response ConnectorExpansionMap
+	err := c.HTTPS.At(fmt.Sprintf(c.Endpoints.Cloud+CONNECTORS_EXPANDED, c.Environment, c.ClusterID)).Get(&response)
 	if err != nil {
-		fmt.Printf("\n Error getting connectors: %v", err)
+		return nil, fmt.Errorf("getting connectors: %w", err)
+	}
+	if err := response.Validate(); err != nil {
 		return nil, err
 	}
-	responseData := response.(map[string]interface{})
 	connectors := make([]ConfluentCloudConnector, 0)
-	for _, connector := range responseData {
-		connectorData := connector.(map[string]interface{})
-		info := connectorData["info"].(map[string]interface{})
-		status := connectorData["status"].(map[string]interface{})
-		id := connectorData["id"].(map[string]interface{})
-		connector := status["connector"].(map[string]interface{})
-
+	for _, connector := range response {
 		connectors = append(connectors, ConfluentCloudConnector{
-			Name:  info["name"].(string),
-			Id:    id["id"].(string),
-			State: ConnectorState(connector["state"].(string)),
-			Type:  connector["type"].(string),
+			Name:  connector.Info.Name,
+			Id:    connector.Id.Id,
+			State: ConnectorState(connector.Status.Connector.State),
+			Type:  connector.Info.Type,
 		})
 	}
 	return connectors, nil
 }
+
+// DeleteConnectors deletes the connectors concurrently, with a result per connector
+func (c *ConfluentCloudClient) DeleteConnectors(names []string) []DeleteResult {
+	return c.deleteEach("Deleting connectors", names, func(ctx context.Context, name string) error {
+		err := c.HTTPS.At(fmt.Sprintf(c.Endpoints.Cloud+CONNECTORS_ENDPOINT+"/%s", c.Environment, c.ClusterID, url.PathEscape(name))).DeleteContext(ctx)
+		if err != nil {
+			return fmt.Errorf("deleting connector %s: %w", name, err)
+		}
+		return nil
+	})
+}
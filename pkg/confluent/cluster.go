@@ -2,13 +2,21 @@ package confluent
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"mcolomer/cloud-keeping/pkg/client"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"net/url"
 
 	"strings"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
 )
 
 type ConfluentCloudCluster struct {
@@ -19,15 +27,14 @@ type ConfluentCloudCluster struct {
 	ClusterAPI        client.HTTPS
 	AdminClient       kafka.AdminClient
 	CrnPatern         string
+	// ApiKey is the cluster API KEY of the Admin client
+	ApiKey string
+	// config of the Kafka clients, e.g. to copy topics
+	config kafka.ConfigMap
 }
 
 func NewKafkaCluster(cluster, bootstrap, rest_endpoint, cluster_api_key, cluster_api_secret, crn_pattern string) (*ConfluentCloudCluster, error) {
 
-	fmt.Println("\n Validating cluster configuration. ")
-	fmt.Println("  - Cluster: ", cluster)
-	fmt.Println("  - Bootstrap: ", bootstrap)
-	fmt.Println("  - Cluster API KEY: ", cluster_api_key)
-
 	// Create a new AdminClient.
 	config := &kafka.ConfigMap{
 		"bootstrap.servers": bootstrap,
@@ -39,8 +46,7 @@ func NewKafkaCluster(cluster, bootstrap, rest_endpoint, cluster_api_key, cluster
 
 	admin, err := kafka.NewAdminClient(config)
 	if err != nil {
-		fmt.Println("Failed to create Admin client:", err)
-		return nil, err
+		return nil, fmt.Errorf("creating Admin client: %w", err)
 	}
 
 	client := client.NewHTTPS(rest_endpoint, cluster_api_key, cluster_api_secret)
@@ -52,160 +58,576 @@ func NewKafkaCluster(cluster, bootstrap, rest_endpoint, cluster_api_key, cluster
 		ClusterAPI:        *client,
 		AdminClient:       *admin,
 		CrnPatern:         crn_pattern,
+		ApiKey:            cluster_api_key,
+		config:            *config,
 	}, nil
 }
 
 // TOPICS
 func (c *ConfluentCloudCluster) GetTopics() ([]string, error) {
-	c.ClusterAPI.Endpoint = fmt.Sprintf(KAFKA_ENDPOINT, c.RestEndpoint, c.ClusterID)
-	response, err := c.ClusterAPI.Get()
+	list, err := c.ListTopics()
 	if err != nil {
-		fmt.Printf("\n Error getting topics: %v", err)
 		return nil, err
 	}
-	responseData := response.(map[string]interface{})
-	spec := responseData[DATA].([]interface{})
 	var topics []string
-	for _, row := range spec {
-		topic := row.(map[string]interface{})[TOPIC_NAME].(string)
-		if !strings.HasPrefix(topic, INTERNAL_PREFIX) {
-			topics = append(topics, topic)
+	for _, topic := range list {
+		topics = append(topics, topic.TopicName)
+	}
+	return topics, nil
+}
+
+// ListTopics returns the topics of the cluster with their partitions, but the internal ones
+func (c *ConfluentCloudCluster) ListTopics() ([]KafkaTopic, error) {
+	var response KafkaTopicList
+	err := c.ClusterAPI.At(fmt.Sprintf(KAFKA_ENDPOINT, c.RestEndpoint, c.ClusterID)).Get(&response)
+	if err != nil {
+		return nil, fmt.Errorf("getting topics: %w", err)
+	}
+	if err := response.Validate(); err != nil {
+		return nil, err
+	}
+	topics := make([]KafkaTopic, 0, len(response.Data))
+	for _, row := range response.Data {
+		if !strings.HasPrefix(row.TopicName, INTERNAL_PREFIX) {
+			topics = append(topics, row)
 		}
 	}
 	return topics, nil
 }
 
-func (c *ConfluentCloudCluster) DeleteTopics(topics []string) []string {
-	// Contexts are used to abort or limit the amount of time
-	// the Admin call blocks waiting for a result.
-	ctx, cancel := context.WithCancel(context.Background())
+// GetConsumerGroups returns the consumer groups of the cluster, a group is removed once its offsets expire
+func (c *ConfluentCloudCluster) GetConsumerGroups() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	result, err := c.AdminClient.ListConsumerGroups(ctx, kafka.SetAdminRequestTimeout(60*time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("listing consumer groups: %w", err)
+	}
+	// A partial list would report the groups of the failed brokers as not found
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("listing consumer groups: %w", errors.Join(result.Errors...))
+	}
+	groups := make([]string, 0, len(result.Valid))
+	for _, g := range result.Valid {
+		groups = append(groups, g.GroupID)
+	}
+	return groups, nil
+}
+
+// GetTransactionalIds returns the transactional ids of the cluster, an id is removed once transactional.id.expiration.ms expires.
+// The Kafka client of the Admin client can't list transactions, a franz-go client is used instead.
+func (c *ConfluentCloudCluster) GetTransactionalIds() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	// Delete topics on cluster
-	// Set Admin options to wait for the operation to finish (or at most 60s)
-	maxDur, err := time.ParseDuration("60s")
+	client, err := c.franzClient()
 	if err != nil {
-		fmt.Println("Error:: ParseDuration(60s):: ", err)
+		return nil, err
 	}
-	results, err := c.AdminClient.DeleteTopics(ctx, topics, kafka.SetAdminOperationTimeout(maxDur))
+	defer client.Close()
+
+	transactions, err := kadm.NewClient(client).ListTransactions(ctx, nil, nil)
 	if err != nil {
-		fmt.Printf("Failed to delete topics: %v\n", err)
+		return nil, fmt.Errorf("listing transactional ids: %w", err)
 	}
+	return transactions.TransactionalIDs(), nil
+}
 
-	// Print results
-	var deletedTopics []string
+// franzClient is a franz-go client with the SASL PLAIN credentials of the Admin client
+func (c *ConfluentCloudCluster) franzClient() (*kgo.Client, error) {
+	brokers := strings.Split(c.BootstrapEndpoint, ",")
+	for i, broker := range brokers {
+		// Confluent Cloud bootstrap endpoints may start with SASL_SSL://
+		if _, address, ok := strings.Cut(broker, "://"); ok {
+			brokers[i] = address
+		}
+	}
+	username, _ := c.config["sasl.username"].(string)
+	password, _ := c.config["sasl.password"].(string)
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.DialTLSConfig(&tls.Config{}),
+		kgo.SASL(plain.Auth{User: username, Pass: password}.AsMechanism()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating Kafka client: %w", err)
+	}
+	return client, nil
+}
+
+// GetTopicConfigs returns the configs set on the topic, without the broker and default configs
+func (c *ConfluentCloudCluster) GetTopicConfigs(topic string) (map[string]string, error) {
+	var response KafkaTopicConfigList
+	err := c.ClusterAPI.At(fmt.Sprintf(TOPIC_CONFIGS, c.RestEndpoint, c.ClusterID, url.PathEscape(topic))).Get(&response)
+	if err != nil {
+		return nil, fmt.Errorf("getting configs of topic %s: %w", topic, err)
+	}
+	if err := response.Validate(); err != nil {
+		return nil, err
+	}
+	configs := make(map[string]string)
+	for _, config := range response.Data {
+		if config.Source == DYNAMIC_TOPIC_CONFIG && config.Value != nil {
+			configs[config.Name] = *config.Value
+		}
+	}
+	return configs, nil
+}
+
+// DeleteTopics deletes the topics, with a result per topic.
+// The context limits the amount of time the Admin call blocks waiting for a result.
+func (c *ConfluentCloudCluster) DeleteTopics(ctx context.Context, topics []string) []DeleteResult {
+	results, err := c.AdminClient.DeleteTopics(ctx, topics, kafka.SetAdminOperationTimeout(operationTimeout(ctx)))
+	deleted := make([]DeleteResult, len(topics))
+	for i, topic := range topics {
+		deleted[i] = DeleteResult{Id: topic, Err: err}
+	}
+	if err != nil {
+		return deleted
+	}
+	errs := make(map[string]error, len(results))
 	for _, result := range results {
-		deletedTopics = append(deletedTopics, result.Topic)
+		if result.Error.Code() != kafka.ErrNoError {
+			errs[result.Topic] = result.Error
+		}
 	}
-	if len(deletedTopics) == 0 {
-		fmt.Println("No topics deleted")
-		return nil
+	for i, topic := range topics {
+		deleted[i].Err = errs[topic]
+	}
+	return deleted
+}
+
+// operationTimeout waits for the operation until the deadline of the context, or at most 60s
+func operationTimeout(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return max(time.Until(deadline), time.Second)
 	}
-	return deletedTopics
+	return 60 * time.Second
 }
 
 // ACLs
 func (c *ConfluentCloudCluster) GetACLs() ([]kafka.ACLBinding, error) {
-	c.ClusterAPI.Endpoint = fmt.Sprintf(ACL_ENDPOINT, c.RestEndpoint, c.ClusterID)
-	fmt.Println("\n Getting ACLs")
-	fmt.Println("  - Endpoint: ", c.ClusterAPI.Endpoint)
-	response, err := c.ClusterAPI.Get()
+	var response KafkaAclList
+	err := c.ClusterAPI.At(fmt.Sprintf(ACL_ENDPOINT, c.RestEndpoint, c.ClusterID)).Get(&response)
 	if err != nil {
-		fmt.Printf("\n Error getting ACLs: %v", err)
+		return nil, fmt.Errorf("getting ACLs: %w", err)
+	}
+	if err := response.Validate(); err != nil {
 		return nil, err
 	}
 
-	responseData := response.(map[string]interface{})
-	spec := responseData[DATA].([]interface{})
-
 	var acls []kafka.ACLBinding
-	for _, row := range spec {
-		var resource_type kafka.ResourceType
-		var resource_pattern_type kafka.ResourcePatternType
-		var operation kafka.ACLOperation
-		var permission kafka.ACLPermissionType
-
-		if row.(map[string]interface{})["resource_type"] != nil {
-			rType := row.(map[string]interface{})["resource_type"].(string)
-			if rType == "CLUSTER" {
-				rType = "BROKER"
-			}
-			res_type, err := kafka.ResourceTypeFromString(rType)
-			if err != nil {
-				fmt.Printf("Invalid resource type: %s: %v\n", row.(map[string]interface{})["resource_type"].(string), err)
-				return nil, err
-			}
-			resource_type = res_type
+	for _, row := range response.Data {
+		// Skip incomplete ACL entries
+		if row.ResourceName == "" || row.Principal == "" || row.Host == "" {
+			continue
 		}
-		if row.(map[string]interface{})["pattern_type"] != nil {
-			pattern_type, err := kafka.ResourcePatternTypeFromString(row.(map[string]interface{})["pattern_type"].(string))
-			if err != nil {
-				fmt.Printf("Invalid resource pattern type: %s: %v\n", row.(map[string]interface{})["pattern_type"].(string), err)
-				return nil, err
-			}
-			resource_pattern_type = pattern_type
+		acl, err := row.toACLBinding()
+		if err != nil {
+			return nil, err
 		}
-		if row.(map[string]interface{})["operation"] != nil {
-			oper, err := kafka.ACLOperationFromString(row.(map[string]interface{})["operation"].(string))
-			if err != nil {
-				fmt.Printf("Invalid operation: %s: %v\n", row.(map[string]interface{})["operation"].(string), err)
-				return nil, err
-			}
-			operation = oper
+		acls = append(acls, acl)
+	}
+	return acls, nil
+}
+
+// DeleteACLs deletes the ACLs, with a result per ACL. An ACL matching no binding fails with ErrNoMatchingACL.
+// The TRANSACTIONAL_ID ACLs, unknown to the Admin client, are deleted with the Kafka REST API.
+// The context limits the amount of time the calls block waiting for a result.
+func (c *ConfluentCloudCluster) DeleteACLs(ctx context.Context, acls []kafka.ACLBinding) []DeleteResult {
+	admin := make([]kafka.ACLBinding, 0, len(acls))
+	for _, acl := range acls {
+		if acl.Type != ResourceTransactionalID {
+			admin = append(admin, acl)
 		}
-		if row.(map[string]interface{})["permission"] != nil {
-			perm, err := kafka.ACLPermissionTypeFromString(row.(map[string]interface{})["permission"].(string))
-			if err != nil {
-				fmt.Printf("Invalid permission: %s: %v\n", row.(map[string]interface{})["permission"].(string), err)
-				return nil, err
-			}
-			permission = perm
+	}
+	var adminResults []DeleteResult
+	if len(admin) > 0 {
+		adminResults = c.deleteAdminACLs(ctx, admin)
+	}
+	deleted := make([]DeleteResult, 0, len(acls))
+	for _, acl := range acls {
+		if acl.Type == ResourceTransactionalID {
+			deleted = append(deleted, c.deleteRestACL(ctx, acl))
+			continue
 		}
-		var name string
-		if row.(map[string]interface{})["resource_name"] != nil {
-			name = row.(map[string]interface{})["resource_name"].(string)
+		deleted = append(deleted, adminResults[0])
+		adminResults = adminResults[1:]
+	}
+	return deleted
+}
+
+// deleteRestACL deletes an ACL with the Kafka REST API, the filter matches the ACL only
+func (c *ConfluentCloudCluster) deleteRestACL(ctx context.Context, acl kafka.ACLBinding) DeleteResult {
+	row := fromACLBinding(acl)
+	filter := url.Values{
+		"resource_type": {row.ResourceType},
+		"resource_name": {row.ResourceName},
+		"pattern_type":  {row.PatternType},
+		"principal":     {row.Principal},
+		"host":          {row.Host},
+		"operation":     {row.Operation},
+		"permission":    {row.Permission},
+	}
+	result := DeleteResult{Id: aclBindingId(acl)}
+	var response KafkaAclList
+	endpoint := fmt.Sprintf(ACL_ENDPOINT, c.RestEndpoint, c.ClusterID) + "?" + filter.Encode()
+	if err := c.ClusterAPI.At(endpoint).DeleteContextInto(ctx, &response); err != nil {
+		result.Err = err
+	} else if len(response.Data) == 0 {
+		result.Err = ErrNoMatchingACL
+	}
+	return result
+}
+
+// deleteAdminACLs deletes the ACLs with the Admin client
+func (c *ConfluentCloudCluster) deleteAdminACLs(ctx context.Context, acls []kafka.ACLBinding) []DeleteResult {
+	results, err := c.AdminClient.DeleteACLs(ctx, acls, kafka.SetAdminRequestTimeout(operationTimeout(ctx)))
+	deleted := make([]DeleteResult, len(acls))
+	for i, acl := range acls {
+		deleted[i] = DeleteResult{Id: aclBindingId(acl), Err: err}
+	}
+	if err != nil {
+		return deleted
+	}
+	// The results are in the order of the filters
+	for i := range deleted {
+		switch {
+		case i >= len(results):
+			deleted[i].Err = fmt.Errorf("no result for ACL %s", deleted[i].Id)
+		case results[i].Error.Code() != kafka.ErrNoError:
+			deleted[i].Err = results[i].Error
+		case len(results[i].ACLBindings) == 0:
+			deleted[i].Err = ErrNoMatchingACL
 		}
-		var principal string
-		if row.(map[string]interface{})["principal"] != nil {
-			principal = row.(map[string]interface{})["principal"].(string)
+	}
+	return deleted
+}
+
+// DescribeTopicConfigs returns the configs of the topics, with their default values
+func (c *ConfluentCloudCluster) DescribeTopicConfigs(topics []string) (map[string]map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	resources := make([]kafka.ConfigResource, len(topics))
+	for i, topic := range topics {
+		resources[i] = kafka.ConfigResource{Type: kafka.ResourceTopic, Name: topic}
+	}
+	results, err := c.AdminClient.DescribeConfigs(ctx, resources)
+	if err != nil {
+		return nil, fmt.Errorf("describing topic configs: %w", err)
+	}
+	configs := make(map[string]map[string]string, len(results))
+	for _, result := range results {
+		if result.Error.Code() != kafka.ErrNoError {
+			return nil, fmt.Errorf("describing configs of topic %s: %w", result.Name, result.Error)
 		}
-		var host string
-		if row.(map[string]interface{})["host"] != nil {
-			host = row.(map[string]interface{})["host"].(string)
+		configs[result.Name] = make(map[string]string, len(result.Config))
+		for name, entry := range result.Config {
+			configs[result.Name][name] = entry.Value
 		}
-		if name == "" || principal == "" || host == "" {
-			fmt.Println("Invalid ACL entry")
+	}
+	return configs, nil
+}
+
+// AlterTopicConfigs sets the configs of a topic, the reset configs are reverted to their default
+func (c *ConfluentCloudCluster) AlterTopicConfigs(topic string, set map[string]string, reset []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	entries := make([]kafka.ConfigEntry, 0, len(set)+len(reset))
+	for name, value := range set {
+		entries = append(entries, kafka.ConfigEntry{Name: name, Value: value, IncrementalOperation: kafka.AlterConfigOpTypeSet})
+	}
+	for _, name := range reset {
+		entries = append(entries, kafka.ConfigEntry{Name: name, IncrementalOperation: kafka.AlterConfigOpTypeDelete})
+	}
+	results, err := c.AdminClient.IncrementalAlterConfigs(ctx, []kafka.ConfigResource{
+		{Type: kafka.ResourceTopic, Name: topic, Config: entries},
+	})
+	if err != nil {
+		return fmt.Errorf("altering configs of topic %s: %w", topic, err)
+	}
+	for _, result := range results {
+		if result.Error.Code() != kafka.ErrNoError {
+			return fmt.Errorf("altering configs of topic %s: %w", topic, result.Error)
+		}
+	}
+	return nil
+}
+
+// CreateACLs creates the ACL bindings, it returns the first failure
+func (c *ConfluentCloudCluster) CreateACLs(acls []kafka.ACLBinding) error {
+	if len(acls) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	results, err := c.AdminClient.CreateACLs(ctx, acls)
+	if err != nil {
+		return fmt.Errorf("creating ACLs: %w", err)
+	}
+	for i, result := range results {
+		if result.Error.Code() != kafka.ErrNoError {
+			return fmt.Errorf("creating ACL %s: %w", aclBindingId(acls[i]), result.Error)
+		}
+	}
+	return nil
+}
+
+// CreateACLsEach creates the ACL bindings and returns a result per binding, in order.
+// The TRANSACTIONAL_ID ACLs, unknown to the Admin client, are created with the Kafka REST API.
+func (c *ConfluentCloudCluster) CreateACLsEach(ctx context.Context, acls []kafka.ACLBinding) []CreateResult {
+	admin := make([]kafka.ACLBinding, 0, len(acls))
+	for _, acl := range acls {
+		if acl.Type != ResourceTransactionalID {
+			admin = append(admin, acl)
+		}
+	}
+	// The Admin request runs along the REST ones
+	adminCh := commons.AsyncCall(func() ([]CreateResult, error) {
+		if len(admin) == 0 {
+			return nil, nil
+		}
+		return c.createAdminACLs(ctx, admin), nil
+	})
+	rest := make(map[int]CreateResult)
+	for i, acl := range acls {
+		if acl.Type == ResourceTransactionalID {
+			rest[i] = c.createRestACL(ctx, acl)
+		}
+	}
+	adminResults := (<-adminCh).Result
+	created := make([]CreateResult, 0, len(acls))
+	for i := range acls {
+		if result, ok := rest[i]; ok {
+			created = append(created, result)
 			continue
 		}
-		acl := kafka.ACLBinding{
-			Type:                resource_type,
-			Name:                name,
-			ResourcePatternType: resource_pattern_type,
-			Principal:           principal,
-			Host:                host,
-			Operation:           operation,
-			PermissionType:      permission,
+		created = append(created, adminResults[0])
+		adminResults = adminResults[1:]
+	}
+	return created
+}
+
+func (c *ConfluentCloudCluster) createRestACL(ctx context.Context, acl kafka.ACLBinding) CreateResult {
+	result := CreateResult{Id: aclBindingId(acl)}
+	body, err := json.Marshal(fromACLBinding(acl))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	endpoint := fmt.Sprintf(ACL_ENDPOINT, c.RestEndpoint, c.ClusterID)
+	result.Err = c.ClusterAPI.At(endpoint).PostContext(ctx, body, nil)
+	return result
+}
+
+func (c *ConfluentCloudCluster) createAdminACLs(ctx context.Context, acls []kafka.ACLBinding) []CreateResult {
+	results, err := c.AdminClient.CreateACLs(ctx, acls, kafka.SetAdminRequestTimeout(operationTimeout(ctx)))
+	created := make([]CreateResult, len(acls))
+	for i, acl := range acls {
+		created[i] = CreateResult{Id: aclBindingId(acl), Err: err}
+	}
+	if err != nil {
+		return created
+	}
+	for i := range created {
+		switch {
+		case i >= len(results):
+			created[i].Err = fmt.Errorf("no result for ACL %s", created[i].Id)
+		case results[i].Error.Code() != kafka.ErrNoError:
+			created[i].Err = results[i].Error
 		}
-		acls = append(acls, acl)
 	}
-	return acls, nil
+	return created
+}
+
+// CreateTopic creates a topic with its configs. A topic still being deleted is retried for up to 2 minutes.
+func (c *ConfluentCloudCluster) CreateTopic(topic string, partitions, replicationFactor int, configs map[string]string) error {
+	spec := kafka.TopicSpecification{
+		Topic:             topic,
+		NumPartitions:     partitions,
+		ReplicationFactor: replicationFactor,
+		Config:            configs,
+	}
+	deadline := time.Now().Add(2 * time.Minute)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		results, err := c.AdminClient.CreateTopics(ctx, []kafka.TopicSpecification{spec}, kafka.SetAdminOperationTimeout(60*time.Second))
+		cancel()
+		if err != nil {
+			return fmt.Errorf("creating topic %s: %w", topic, err)
+		}
+		if len(results) == 0 || results[0].Error.Code() == kafka.ErrNoError {
+			return nil
+		}
+		if results[0].Error.Code() != kafka.ErrTopicAlreadyExists || time.Now().After(deadline) {
+			return fmt.Errorf("creating topic %s: %w", topic, results[0].Error)
+		}
+		time.Sleep(5 * time.Second)
+	}
 }
 
-func (c *ConfluentCloudCluster) DeleteACLs(acls []kafka.ACLBinding) ([]kafka.DescribeACLsResult, error) {
-	// Contexts are used to abort or limit the amount of time
-	// the Admin call blocks waiting for a result.
-	ctx, cancel := context.WithCancel(context.Background())
+// DeleteTopic deletes a topic, a missing topic is not an error
+func (c *ConfluentCloudCluster) DeleteTopic(topic string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	// Delete ACLs on cluster
-	// Set Admin options to wait for the operation to finish (or at most 60s)
-	maxDur, err := time.ParseDuration("60s")
+	results, err := c.AdminClient.DeleteTopics(ctx, []string{topic}, kafka.SetAdminOperationTimeout(60*time.Second))
+	if err != nil {
+		return fmt.Errorf("deleting topic %s: %w", topic, err)
+	}
+	for _, result := range results {
+		if code := result.Error.Code(); code != kafka.ErrNoError && code != kafka.ErrUnknownTopicOrPart {
+			return fmt.Errorf("deleting topic %s: %w", topic, result.Error)
+		}
+	}
+	return nil
+}
+
+// CopyTopic produces the records of a topic, up to its current end, to another topic.
+// Keys, values, headers and timestamps are kept, records are partitioned by key as the Java clients do.
+// It returns the number of copied records.
+func (c *ConfluentCloudCluster) CopyTopic(ctx context.Context, from, to string) (int, error) {
+	consumerConfig := c.clientConfig(kafka.ConfigMap{
+		"group.id":             "cleanup-copy-" + from,
+		"enable.auto.commit":   false,
+		"enable.partition.eof": true,
+		"isolation.level":      "read_committed",
+	})
+	consumer, err := kafka.NewConsumer(&consumerConfig)
 	if err != nil {
-		fmt.Println("Error:: ParseDuration(60s):: ", err)
+		return 0, fmt.Errorf("creating consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	metadata, err := consumer.GetMetadata(&from, false, 30000)
+	if err != nil {
+		return 0, fmt.Errorf("getting partitions of topic %s: %w", from, err)
+	}
+	partitions := metadata.Topics[from].Partitions
+	if len(partitions) == 0 {
+		return 0, fmt.Errorf("topic %s has no partitions", from)
+	}
+	assignment := make([]kafka.TopicPartition, len(partitions))
+	for i, p := range partitions {
+		assignment[i] = kafka.TopicPartition{Topic: &from, Partition: p.ID, Offset: kafka.OffsetBeginning}
+	}
+	if err := consumer.Assign(assignment); err != nil {
+		return 0, fmt.Errorf("assigning partitions of topic %s: %w", from, err)
+	}
+
+	producerConfig := c.clientConfig(kafka.ConfigMap{
+		"enable.idempotence": true,
+		"partitioner":        "murmur2_random",
+	})
+	producer, err := kafka.NewProducer(&producerConfig)
+	if err != nil {
+		return 0, fmt.Errorf("creating producer: %w", err)
+	}
+	copied, err := copyRecords(ctx, consumer, producer, to, len(partitions))
+	if err != nil {
+		return copied, fmt.Errorf("copying topic %s to %s: %w", from, to, err)
+	}
+	return copied, nil
+}
+
+// recordPoller is the consumer of the copied records
+type recordPoller interface {
+	Poll(timeoutMs int) kafka.Event
+}
+
+// copyRecords produces the polled records to a topic until the end of every partition and closes the producer.
+// On error the records not yet delivered are purged, the producer is closed before its delivery channel.
+func copyRecords(ctx context.Context, consumer recordPoller, producer *kafka.Producer, to string, partitions int) (int, error) {
+	// Delivery failures, the first one is returned
+	deliveries := make(chan kafka.Event, 1000)
+	failures := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range deliveries {
+			if m, ok := e.(*kafka.Message); ok && m.TopicPartition.Error != nil {
+				select {
+				case failures <- m.TopicPartition.Error:
+				default:
+				}
+			}
+		}
+	}()
+
+	var err error
+	copied := 0
+	ended := make(map[int32]bool, partitions)
+	for len(ended) < partitions {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+		switch e := consumer.Poll(100).(type) {
+		case *kafka.Message:
+			record := &kafka.Message{
+				TopicPartition: kafka.TopicPartition{Topic: &to, Partition: kafka.PartitionAny},
+				Key:            e.Key,
+				Value:          e.Value,
+				Headers:        e.Headers,
+				Timestamp:      e.Timestamp,
+			}
+			// Wait for the deliveries when the producer queue is full
+			for err = producer.Produce(record, deliveries); isQueueFull(err); err = producer.Produce(record, deliveries) {
+				producer.Flush(100)
+			}
+			if err == nil {
+				copied++
+			}
+		case kafka.PartitionEOF:
+			ended[e.Partition] = true
+		case kafka.Error:
+			if e.IsFatal() || e.Code() == kafka.ErrAllBrokersDown {
+				err = e
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	for err == nil && producer.Flush(1000) > 0 {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
 	}
-	results, err := c.AdminClient.DeleteACLs(ctx, acls, kafka.SetAdminRequestTimeout(maxDur))
 	if err != nil {
-		fmt.Printf("Failed to delete ACLs: %v\n", err)
+		// The purged records are reported as failed deliveries
+		producer.Purge(kafka.PurgeQueue | kafka.PurgeInFlight)
+		producer.Flush(1000)
+	}
+	producer.Close()
+	close(deliveries)
+	<-done
+	if err == nil {
+		select {
+		case err = <-failures:
+		default:
+		}
+	}
+	return copied, err
+}
+
+func isQueueFull(err error) bool {
+	kafkaErr, ok := err.(kafka.Error)
+	return ok && kafkaErr.Code() == kafka.ErrQueueFull
+}
+
+// clientConfig returns the config of the Kafka clients with additional properties
+func (c *ConfluentCloudCluster) clientConfig(properties kafka.ConfigMap) kafka.ConfigMap {
+	config := make(kafka.ConfigMap, len(c.config)+len(properties))
+	for k, v := range c.config {
+		config[k] = v
+	}
+	for k, v := range properties {
+		config[k] = v
 	}
-	return results, nil
+	return config
 }
@@ -0,0 +1,41 @@
+package confluent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// endlessPoller returns records and cancels the copy after some of them
+type endlessPoller struct {
+	polled int
+	cancel func()
+}
+
+func (p *endlessPoller) Poll(timeoutMs int) kafka.Event {
+	p.polled++
+	if p.polled == 50 {
+		p.cancel()
+	}
+	topic := "orders"
+	return &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &topic}, Value: []byte("record")}
+}
+
+func TestCopyRecordsCancelled(t *testing.T) {
+	// Without a broker the records stay in flight until they are purged
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": "localhost:1", "log_level": 0, "message.timeout.ms": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	copied, err := copyRecords(ctx, &endlessPoller{cancel: cancel}, producer, "orders-copy", 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("copyRecords() error = %v, want %v", err, context.Canceled)
+	}
+	if copied != 50 {
+		t.Errorf("copyRecords() = %d copied, want 50", copied)
+	}
+}
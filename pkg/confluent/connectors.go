@@ -0,0 +1,68 @@
+package confluent
+
+import (
+	"context"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"sort"
+	"time"
+)
+
+// ConnectorCleaner detects FAILED connectors
+type ConnectorCleaner struct {
+	clean *ConfluentClean
+}
+
+func (c *ConnectorCleaner) Name() string {
+	return "connectors"
+}
+
+func (c *ConnectorCleaner) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := c.clean.newReport(c.Name())
+	report.StartedAt = time.Now()
+
+	connectors, err := c.clean.CloudAPI.GetConnectors()
+	if err != nil {
+		return report, err
+	}
+	sort.Slice(connectors, func(i, j int) bool { return connectors[i].Name < connectors[j].Name })
+
+	for _, connector := range connectors {
+		report.Findings = append(report.Findings, cleaner.Finding{
+			Kind:   cleaner.KindConnector,
+			Id:     connector.Name,
+			Status: string(connector.State),
+			Attributes: map[string]string{
+				AttrConnectorId: connector.Id,
+				AttrType:        connector.Type,
+			},
+			Candidate: connector.State == FAILED,
+			Resource:  connector,
+		})
+	}
+	err = c.clean.applyCatalog(&report, EntityConnector, func(f cleaner.Finding) string {
+		return c.clean.Catalog.QualifiedName(f.Attributes[AttrConnectorId])
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func (c *ConnectorCleaner) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	names := make([]string, len(plan.Findings))
+	for i, f := range plan.Findings {
+		names[i] = f.Id
+	}
+	for i, deleted := range c.clean.CloudAPI.DeleteConnectors(names) {
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:       cleaner.KindConnector,
+			Id:         deleted.Id,
+			Action:     cleaner.ActionDelete,
+			PriorState: plan.Findings[i].Resource,
+			Err:        deleted.Err,
+		})
+	}
+	return result, nil
+}
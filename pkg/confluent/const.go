@@ -6,8 +6,22 @@ const (
 	InactiveStatus = "INACTIVE"
 
 	// ACL status constants
-	TopicNotFoundStatus       = "TOPIC_NOT_FOUND"
-	TopicPrefixNotFoundStatus = "TOPIC_PREFIX_NOT_FOUND"
+	TopicNotFoundStatus                 = "TOPIC_NOT_FOUND"
+	TopicPrefixNotFoundStatus           = "TOPIC_PREFIX_NOT_FOUND"
+	PrincipalNotFoundStatus             = "PRINCIPAL_NOT_FOUND"
+	GroupNotFoundStatus                 = "GROUP_NOT_FOUND"
+	GroupPrefixNotFoundStatus           = "GROUP_PREFIX_NOT_FOUND"
+	TransactionalIdNotFoundStatus       = "TRANSACTIONAL_ID_NOT_FOUND"
+	TransactionalIdPrefixNotFoundStatus = "TRANSACTIONAL_ID_PREFIX_NOT_FOUND"
+	// UnverifiedStatus ACLs can't be checked, e.g. no consumer group is visible to the cluster API KEY
+	UnverifiedStatus = "UNVERIFIED"
+
+	// Role binding status constants
+	ClusterNotFoundStatus         = "CLUSTER_NOT_FOUND"
+	SubjectNotFoundStatus         = "SUBJECT_NOT_FOUND"
+	SubjectPrefixNotFoundStatus   = "SUBJECT_PREFIX_NOT_FOUND"
+	ConnectorNotFoundStatus       = "CONNECTOR_NOT_FOUND"
+	ConnectorPrefixNotFoundStatus = "CONNECTOR_PREFIX_NOT_FOUND"
 
 	// Service account status constants
 	ActiveServiceAccount   = "YES"
@@ -23,22 +37,42 @@ const (
 
 	StatusHeader = "Status"
 
+	// Finding attributes
+	AttrApiKeys      = "api_keys"
+	AttrRoleBindings = "role_bindings"
+	AttrConnectorId  = "connector_id"
+	AttrType         = "type"
+
 	// The Confluent Cloud API endpoint
 	CONFLUENT_ENDPOINT = "https://api.confluent.cloud"
 	//CLUSTER
-	KAFKA_ENDPOINT  = "%s/kafka/v3/clusters/%s/topics"
-	INTERNAL_PREFIX = "__"
-	DATA            = "data"
-	TOPIC_NAME      = "topic_name"
-	SASL_SSL        = "SASL_SSL"
-	PLAIN           = "PLAIN"
+	KAFKA_ENDPOINT = "%s/kafka/v3/clusters/%s/topics"
+	TOPIC_CONFIGS  = KAFKA_ENDPOINT + "/%s/configs"
+	// Source of the configs set on a topic
+	DYNAMIC_TOPIC_CONFIG = "DYNAMIC_TOPIC_CONFIG"
+	INTERNAL_PREFIX      = "__"
+	DATA                 = "data"
+	TOPIC_NAME           = "topic_name"
+	SASL_SSL             = "SASL_SSL"
+	PLAIN                = "PLAIN"
 	//ACL
 	ACL_ENDPOINT = "%s/kafka/v3/clusters/%s/acls"
 	//OPERATIONS
-	CLUSTER = "/cmk/v2/clusters/%s?environment=%s"
+	CLUSTER  = "/cmk/v2/clusters/%s?environment=%s"
+	CLUSTERS = "/cmk/v2/clusters?environment=%s"
 	//API KEYS
 	API_KEYS         = "/iam/v2/api-keys"
 	CLUSTER_API_KEYS = API_KEYS + "?spec.resource=%s"
+	//IAM
+	SERVICE_ACCOUNTS   = "/iam/v2/service-accounts"
+	USERS              = "/iam/v2/users"
+	IDENTITY_PROVIDERS = "/iam/v2/identity-providers"
+	IDENTITY_POOLS     = IDENTITY_PROVIDERS + "/%s/identity-pools"
 	//RBAC
-	RBAC_ENDPOINT = "/iam/v2/role-bindings?principal=User:%s&crn_pattern=%s"
+	ROLE_BINDINGS     = "/iam/v2/role-bindings"
+	RBAC_ENDPOINT     = ROLE_BINDINGS + "?principal=User:%s&crn_pattern=%s"
+	RBAC_CRN_ENDPOINT = ROLE_BINDINGS + "?crn_pattern=%s"
+	//CONNECT
+	CONNECTORS_ENDPOINT = "/connect/v1/environments/%s/clusters/%s/connectors"
+	CONNECTORS_EXPANDED = CONNECTORS_ENDPOINT + "?expand=info,status,id"
 )
@@ -0,0 +1,67 @@
+package confluent
+
+import (
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"strconv"
+)
+
+const (
+	// Bytes of a billed GB
+	GB = 1 << 30
+
+	// Finding attributes
+	AttrRetainedBytes = "retained_bytes"
+	AttrPartitions    = "partitions"
+	AttrMonthlyCost   = "monthly_cost"
+)
+
+// Prices to estimate the monthly cost of the resources
+type Prices struct {
+	// Storage, $/GB-month
+	GBMonth float64
+	// $/partition-month
+	PartitionMonth float64
+}
+
+// DefaultPrices: storage list price, partitions are included in most cluster types
+var DefaultPrices = Prices{GBMonth: 0.10, PartitionMonth: 0}
+
+// TopicUsage is the storage and partitions of a topic
+type TopicUsage struct {
+	RetainedBytes float64
+	Partitions    int
+}
+
+// Monthly is the estimated monthly cost of a topic
+func (p Prices) Monthly(u TopicUsage) float64 {
+	return u.RetainedBytes/GB*p.GBMonth + float64(u.Partitions)*p.PartitionMonth
+}
+
+// setUsage adds the topic usage and its estimated monthly cost to a finding
+func (p Prices) setUsage(f *cleaner.Finding, u TopicUsage) {
+	if f.Attributes == nil {
+		f.Attributes = make(map[string]string)
+	}
+	f.Attributes[AttrRetainedBytes] = strconv.FormatFloat(u.RetainedBytes, 'f', 0, 64)
+	f.Attributes[AttrPartitions] = strconv.Itoa(u.Partitions)
+	f.Attributes[AttrMonthlyCost] = fmt.Sprintf("%.2f", p.Monthly(u))
+}
+
+// Usage reads the topic usage of a finding
+func Usage(f cleaner.Finding) TopicUsage {
+	retained, _ := strconv.ParseFloat(f.Attributes[AttrRetainedBytes], 64)
+	partitions, _ := strconv.Atoi(f.Attributes[AttrPartitions])
+	return TopicUsage{RetainedBytes: retained, Partitions: partitions}
+}
+
+// Reclaimable sums the usage of the deletion candidates of a report
+func Reclaimable(report cleaner.Report) TopicUsage {
+	var total TopicUsage
+	for _, f := range report.Candidates() {
+		u := Usage(f)
+		total.RetainedBytes += u.RetainedBytes
+		total.Partitions += u.Partitions
+	}
+	return total
+}
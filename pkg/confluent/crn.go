@@ -0,0 +1,57 @@
+package confluent
+
+import (
+	"strings"
+)
+
+// CRN are the resource parts of a Confluent Resource Name pattern, e.g.
+// crn://confluent.cloud/organization=o/environment=env-xxxxx/cloud-cluster=lkc-xxxxx/kafka=lkc-xxxxx/topic=orders-*
+type CRN struct {
+	Organization    string
+	Environment     string
+	CloudCluster    string
+	Kafka           string
+	Topic           string
+	Group           string
+	TransactionalId string
+	SchemaRegistry  string
+	Subject         string
+	Connector       string
+}
+
+// ParseCRN splits a CRN pattern into its parts, unknown parts are ignored
+func ParseCRN(pattern string) CRN {
+	var crn CRN
+	parts := map[string]*string{
+		"organization":     &crn.Organization,
+		"environment":      &crn.Environment,
+		"cloud-cluster":    &crn.CloudCluster,
+		"kafka":            &crn.Kafka,
+		"topic":            &crn.Topic,
+		"group":            &crn.Group,
+		"transactional-id": &crn.TransactionalId,
+		"schema-registry":  &crn.SchemaRegistry,
+		"subject":          &crn.Subject,
+		"connector":        &crn.Connector,
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(pattern, "crn://"), "/") {
+		key, value, ok := strings.Cut(segment, "=")
+		if field, known := parts[key]; ok && known {
+			*field = value
+		}
+	}
+	return crn
+}
+
+// Cluster is the Kafka cluster of the CRN, of a kafka or a connector resource
+func (c CRN) Cluster() string {
+	if c.Kafka != "" {
+		return c.Kafka
+	}
+	return c.CloudCluster
+}
+
+// namePattern splits a CRN resource name into its prefix when it ends with *, e.g. orders-* is the prefix orders-
+func namePattern(name string) (string, bool) {
+	return strings.CutSuffix(name, "*")
+}
@@ -0,0 +1,48 @@
+package confluent
+
+import (
+	"mcolomer/cloud-keeping/pkg/commons"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// TopicDetails are the configs, activity and ACLs of a topic
+type TopicDetails struct {
+	Configs map[string]string
+	// DailyRecords are the received records per day of the last week, oldest first
+	DailyRecords []float64
+	// Acls are the TOPIC ACLs matching the topic
+	Acls []kafka.ACLBinding
+}
+
+// TopicDetails gets the details of a topic
+func (c *ConfluentClean) TopicDetails(topic string) (TopicDetails, error) {
+	details := TopicDetails{}
+	configsCh := commons.AsyncCall(func() (map[string]string, error) {
+		return c.CloudAPI.KafkaCluster.GetTopicConfigs(topic)
+	})
+	recordsCh := commons.AsyncCall(func() ([]float64, error) {
+		return c.MetricsAPI.GetTopicDailyRecords(topic)
+	})
+	aclsCh := commons.AsyncCall(func() ([]kafka.ACLBinding, error) {
+		return c.CloudAPI.GetACLs()
+	})
+	configs, records, acls := <-configsCh, <-recordsCh, <-aclsCh
+	if configs.Err != nil {
+		return details, configs.Err
+	}
+	if records.Err != nil {
+		return details, records.Err
+	}
+	if acls.Err != nil {
+		return details, acls.Err
+	}
+	details.Configs = configs.Result
+	details.DailyRecords = records.Result
+	for _, acl := range acls.Result {
+		if aclMatchesTopic(acl, topic) {
+			details.Acls = append(details.Acls, acl)
+		}
+	}
+	return details, nil
+}
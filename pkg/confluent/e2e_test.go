@@ -0,0 +1,112 @@
+package confluent
+
+import (
+	"context"
+	"maps"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/fakecloud"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// newFakeClean connects to a fake server of the seed of docs/fake-server-seed.yaml
+func newFakeClean(t *testing.T) *ConfluentClean {
+	seed, err := fakecloud.LoadSeed("../../docs/fake-server-seed.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(fakecloud.NewServer(seed))
+	t.Cleanup(server.Close)
+	clean, err := NewConfluentClean(Options{
+		Environment:             "env-fake01",
+		Cluster:                 "lkc-fake01",
+		ClusterApiKey:           "key",
+		ClusterApiSecret:        "secret",
+		CloudApiKey:             "FAKEKEY01",
+		CloudApiSecret:          "secret",
+		SchemaRegistryApiKey:    "key",
+		SchemaRegistryApiSecret: "secret",
+		Endpoints:               Endpoints{Cloud: server.URL, Metrics: server.URL, SchemaRegistry: server.URL},
+	})
+	if err != nil {
+		t.Fatalf("NewConfluentClean() error = %v", err)
+	}
+	t.Cleanup(clean.Close)
+	return clean
+}
+
+func findingStatus(report cleaner.Report) map[string]string {
+	status := make(map[string]string, len(report.Findings))
+	for _, f := range report.Findings {
+		status[f.Id] = f.Status
+	}
+	return status
+}
+
+func TestFakeCloudScan(t *testing.T) {
+	clean := newFakeClean(t)
+	tests := []struct {
+		cleaner cleaner.Cleaner
+		want    map[string]string
+		// deleted is the candidate of the scan
+		deleted string
+	}{
+		{clean.Connectors(), map[string]string{"s3-sink": "RUNNING", "broken-source": "FAILED"}, "broken-source"},
+		{clean.ServiceAccounts(), map[string]string{"sa-app01": ActiveStatus, "sa-old01": InactiveStatus}, "sa-old01"},
+		{clean.Schemas(), map[string]string{"orders-value": ActiveStatus, "legacy-orders-value": TopicNotFoundStatus, "retired-value": ProtectedStatus}, "legacy-orders-value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cleaner.Name(), func(t *testing.T) {
+			report, err := tt.cleaner.Scan(context.Background())
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if got := findingStatus(report); !maps.Equal(got, tt.want) {
+				t.Errorf("Scan() = %v, want %v", got, tt.want)
+			}
+			plan := cleaner.NewPlan(report)
+			if len(plan.Findings) != 1 || plan.Findings[0].Id != tt.deleted {
+				t.Fatalf("NewPlan() = %+v, want %s", plan.Findings, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestAllCleanerNames(t *testing.T) {
+	names := make([]string, 0, len(CleanerNames))
+	for _, c := range newFakeClean(t).AllCleaners() {
+		names = append(names, c.Name())
+	}
+	if !slices.Equal(names, CleanerNames) {
+		t.Errorf("AllCleaners() = %v, want %v", names, CleanerNames)
+	}
+}
+
+func TestFakeCloudApply(t *testing.T) {
+	clean := newFakeClean(t)
+	for _, c := range []cleaner.Cleaner{clean.Connectors(), clean.Schemas()} {
+		t.Run(c.Name(), func(t *testing.T) {
+			report, err := c.Scan(context.Background())
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			plan := cleaner.NewPlan(report)
+			result, err := c.Apply(context.Background(), plan)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if len(result.Succeeded()) != len(plan.Findings) {
+				t.Errorf("Apply() = %+v, want %d deleted", result.Items, len(plan.Findings))
+			}
+			// The deleted resources are gone from the next scan
+			report, err = c.Scan(context.Background())
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if candidates := report.Candidates(); len(candidates) != 0 {
+				t.Errorf("Scan() after Apply() candidates = %+v, want none", candidates)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package confluent
+
+import "strings"
+
+// Endpoints holds the base URLs used to reach Confluent Cloud.
+// All of them can be overridden, to point the cleaners to a proxy or to the bundled fake server.
+type Endpoints struct {
+	// Confluent Cloud API: cmk, iam and connect
+	Cloud string
+	// Confluent Cloud Metrics API
+	Metrics string
+	// Kafka REST endpoint, when empty the cluster http_endpoint is used
+	KafkaRest string
+	// Kafka bootstrap servers, when empty the cluster kafka_bootstrap_endpoint is used
+	KafkaBootstrap string
+	// Schema Registry endpoint of the Stream Catalog, when empty the environment Schema Registry is used
+	SchemaRegistry string
+}
+
+// WithDefaults fills the empty base URLs with the Confluent Cloud defaults
+func (e Endpoints) WithDefaults() Endpoints {
+	if e.Cloud == "" {
+		e.Cloud = CONFLUENT_ENDPOINT
+	}
+	if e.Metrics == "" {
+		e.Metrics = METRICS_ENDPOINT
+	}
+	e.Cloud = strings.TrimSuffix(e.Cloud, "/")
+	e.Metrics = strings.TrimSuffix(e.Metrics, "/")
+	e.KafkaRest = strings.TrimSuffix(e.KafkaRest, "/")
+	e.SchemaRegistry = strings.TrimSuffix(e.SchemaRegistry, "/")
+	return e
+}
@@ -0,0 +1,127 @@
+package confluent
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ServiceAccountUsage is the cluster access of a service account
+type ServiceAccountUsage struct {
+	Principal    string
+	Connections  float64
+	ApiKeys      []string
+	RoleBindings []ConfluentCloudRoleBinding
+}
+
+// ServiceAccountCleaner detects service accounts with cluster API keys and no requests in the last 7 days.
+// Their cluster API keys and cluster role bindings are deleted.
+type ServiceAccountCleaner struct {
+	clean *ConfluentClean
+}
+
+func (s *ServiceAccountCleaner) Name() string {
+	return "service-accounts"
+}
+
+func (s *ServiceAccountCleaner) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := s.clean.newReport(s.Name())
+	report.StartedAt = time.Now()
+
+	principalWithKeysCh := commons.AsyncCall(func() (map[string][]string, error) {
+		return s.clean.CloudAPI.GetClusterApiKeys()
+	})
+	principalsConnectionsCh := commons.AsyncCall(func() (map[string]float64, error) {
+		return s.clean.MetricsAPI.GetConnectionsByServiceAccount()
+	})
+
+	principalWithKeys := <-principalWithKeysCh
+	principalsCon := <-principalsConnectionsCh
+
+	if principalWithKeys.Err != nil {
+		return report, principalWithKeys.Err
+	}
+	if principalsCon.Err != nil {
+		return report, fmt.Errorf("getting service account connections: %w", principalsCon.Err)
+	}
+
+	principals := make([]string, 0, len(principalWithKeys.Result))
+	for principal := range principalWithKeys.Result {
+		if strings.Contains(principal, SERVICE_ACCOUNT) {
+			principals = append(principals, principal)
+		}
+	}
+	sort.Strings(principals)
+
+	for _, principal := range principals {
+		roleBindings, err := s.clean.CloudAPI.GetRoleBindings(principal)
+		if err != nil {
+			return report, err
+		}
+		usage := ServiceAccountUsage{
+			Principal:    principal,
+			Connections:  principalsCon.Result[principal],
+			ApiKeys:      principalWithKeys.Result[principal],
+			RoleBindings: roleBindings,
+		}
+		roles := make([]string, len(roleBindings))
+		for i, rb := range roleBindings {
+			roles[i] = rb.Role
+		}
+		finding := cleaner.Finding{
+			Kind:   cleaner.KindServiceAccount,
+			Id:     principal,
+			Status: ActiveStatus,
+			Attributes: map[string]string{
+				AttrApiKeys:      strings.Join(usage.ApiKeys, ", "),
+				AttrRoleBindings: strings.Join(roles, ", "),
+			},
+			Resource: usage,
+		}
+		if usage.Connections <= 0 {
+			finding.Status = InactiveStatus
+			finding.Candidate = true
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func (s *ServiceAccountCleaner) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	for _, f := range plan.Findings {
+		usage, ok := f.Resource.(ServiceAccountUsage)
+		if !ok {
+			continue
+		}
+		for _, deleted := range s.clean.CloudAPI.DeleteApiKeys(usage.ApiKeys) {
+			result.Items = append(result.Items, cleaner.ItemResult{
+				Kind:       cleaner.KindApiKey,
+				Id:         deleted.Id,
+				Action:     cleaner.ActionDelete,
+				PriorState: ApiKey{Id: deleted.Id, Spec: ApiKeySpec{Owner: ObjectReference{Id: usage.Principal}, Resource: ObjectReference{Id: s.clean.CloudAPI.ClusterID}}},
+				Err:        deleted.Err,
+			})
+		}
+		ids := make([]string, len(usage.RoleBindings))
+		for i, rb := range usage.RoleBindings {
+			ids[i] = rb.Id
+		}
+		for i, deleted := range s.clean.CloudAPI.DeleteRoleBindings(ids) {
+			rb := usage.RoleBindings[i]
+			result.Items = append(result.Items, cleaner.ItemResult{
+				Kind:       cleaner.KindRoleBinding,
+				Id:         deleted.Id,
+				Action:     cleaner.ActionDelete,
+				PriorState: RoleBinding{Id: rb.Id, Principal: rb.Principal, RoleName: rb.Role, CrnPattern: rb.Resource},
+				Err:        deleted.Err,
+			})
+		}
+	}
+	return result, nil
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"mcolomer/cloud-keeping/pkg/client" // Import the package that defines the HTTPS type
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 )
@@ -17,10 +19,12 @@ type MetricDescriptor struct {
 	Metric string `json:"metric"`
 }
 
+// MetricFilter is a field filter, or an AND of filters
 type MetricFilter struct {
-	Field string `json:"field"`
-	Op    string `json:"op"`
-	Value string `json:"value"`
+	Field   string         `json:"field,omitempty"`
+	Op      string         `json:"op"`
+	Value   string         `json:"value,omitempty"`
+	Filters []MetricFilter `json:"filters,omitempty"`
 }
 
 type ConfluentCloudMetricsQuery struct {
@@ -34,40 +38,51 @@ type ConfluentCloudMetricsQuery struct {
 
 const (
 	// The Confluent Cloud Metrics API endpoint
-	METRICS_ENDPOINT = "https://api.telemetry.confluent.cloud/v2/metrics/cloud/query"
+	METRICS_ENDPOINT = "https://api.telemetry.confluent.cloud"
+	METRICS_QUERY    = "/v2/metrics/cloud/query"
+	PAGE_TOKEN       = "?page_token=%s"
 	METRIC_TOPIC     = "metric.topic"
 	METRIC_PRINCIPAL = "metric.principal_id"
 
 	METRICS_RECEIVED_RECORDS   = "io.confluent.kafka.server/received_records"
+	METRICS_RECEIVED_BYTES     = "io.confluent.kafka.server/received_bytes"
 	METRICS_ACTIVE_CONNECTIONS = "io.confluent.kafka.server/request_count"
+	METRICS_RETAINED_BYTES     = "io.confluent.kafka.server/retained_bytes"
 
 	FIELD           = "resource.kafka.id"
 	OPEREATION_EQ   = "eq"
+	OPERATION_AND   = "AND"
 	GRANULARITY_1_D = "P1D"
 	LIMIT           = 1000
 
 	SERVICE_ACCOUNT = "sa-"
 )
 
-func NewConfluentCloudMetricsClient(cluster, cluster_api_key, cluster_api_secret string) (*ConfluentCloudMetricsClient, error) {
+func NewConfluentCloudMetricsClient(endpoint, cluster, cluster_api_key, cluster_api_secret string) (*ConfluentCloudMetricsClient, error) {
 	metricsClient := &ConfluentCloudMetricsClient{}
-	metricsClient.HTTPS = *client.NewHTTPS(METRICS_ENDPOINT, cluster_api_key, cluster_api_secret)
+	metricsClient.HTTPS = *client.NewHTTPS(endpoint+METRICS_QUERY, cluster_api_key, cluster_api_secret)
 	metricsClient.Cluster = cluster
 	return metricsClient, nil
 }
 
-func (c *ConfluentCloudMetricsClient) QueryMetric(metric string, group string) (map[string]interface{}, error) {
+// QueryMetric returns the daily data points of the last week of a metric of the cluster, grouped by a label.
+// The filters are added to the cluster filter, e.g. a topic. Every page of the response is read.
+func (c *ConfluentCloudMetricsClient) QueryMetric(metric string, group string, filters ...MetricFilter) (*MetricsQueryResponse, error) {
+	filter := MetricFilter{
+		Field: FIELD,
+		Op:    OPEREATION_EQ,
+		Value: c.Cluster,
+	}
+	if len(filters) > 0 {
+		filter = MetricFilter{Op: OPERATION_AND, Filters: append([]MetricFilter{filter}, filters...)}
+	}
 	query := &ConfluentCloudMetricsQuery{
 		Aggregations: []MetricDescriptor{
 			{
 				Metric: metric,
 			},
 		},
-		Filter: MetricFilter{
-			Field: FIELD,
-			Op:    OPEREATION_EQ,
-			Value: c.Cluster,
-		},
+		Filter:      filter,
 		Granularity: GRANULARITY_1_D,
 		GroupBy:     []string{group},
 		Intervals:   []string{getLastWeekRange()},
@@ -79,12 +94,25 @@ func (c *ConfluentCloudMetricsClient) QueryMetric(metric string, group string) (
 		return nil, err
 	}
 
-	response, err := c.HTTPS.Post(queryBytes)
-	if err != nil {
-		fmt.Printf("\nError getting active topics: %v", err)
-		return nil, err
+	response := MetricsQueryResponse{Data: make([]MetricsDataPoint, 0)}
+	endpoint := c.HTTPS.Endpoint
+	for endpoint != "" {
+		var page MetricsQueryResponse
+		err = c.HTTPS.At(endpoint).Post(queryBytes, &page)
+		if err != nil {
+			fmt.Printf("\nError querying metric %s: %v", metric, err)
+			return nil, err
+		}
+		if err := page.Validate(group); err != nil {
+			return nil, err
+		}
+		response.Data = append(response.Data, page.Data...)
+		endpoint = ""
+		if token := page.Meta.Pagination.NextPageToken; token != "" {
+			endpoint = c.HTTPS.Endpoint + fmt.Sprintf(PAGE_TOKEN, url.QueryEscape(token))
+		}
 	}
-	return response.(map[string]interface{}), nil
+	return &response, nil
 }
 
 /** io.confluent.kafka.server/request_count
@@ -98,11 +126,9 @@ func (c *ConfluentCloudMetricsClient) GetConnectionsByServiceAccount() (map[stri
 	if err != nil {
 		return principals, err
 	}
-	for _, row := range responseData["data"].([]interface{}) {
-		principal := row.(map[string]interface{})[METRIC_PRINCIPAL].(string)
-		value := row.(map[string]interface{})["value"].(float64)
-		if strings.Contains(principal, SERVICE_ACCOUNT) {
-			principals[principal] = principals[principal] + value
+	for _, row := range responseData.Data {
+		if strings.Contains(row.PrincipalId, SERVICE_ACCOUNT) {
+			principals[row.PrincipalId] = principals[row.PrincipalId] + row.Value
 		}
 	}
 	return principals, nil
@@ -116,14 +142,99 @@ func (c *ConfluentCloudMetricsClient) GetActiveTopics() ([]string, error) {
 	if err != nil {
 		return topics, err
 	}
-	for _, row := range responseData["data"].([]interface{}) {
-		topic := row.(map[string]interface{})[METRIC_TOPIC].(string)
-		topics = append(topics, topic)
+	for _, row := range responseData.Data {
+		topics = append(topics, row.Topic)
 	}
 	return topics, nil
 
 }
 
+// GetRetainedBytes returns the latest io.confluent.kafka.server/retained_bytes of every topic
+func (c *ConfluentCloudMetricsClient) GetRetainedBytes() (map[string]float64, error) {
+	retained := make(map[string]float64)
+	responseData, err := c.QueryMetric(METRICS_RETAINED_BYTES, METRIC_TOPIC)
+	if err != nil {
+		return retained, err
+	}
+	// Daily data points, the latest one is kept
+	latest := make(map[string]string)
+	for _, row := range responseData.Data {
+		if row.Timestamp >= latest[row.Topic] {
+			latest[row.Topic] = row.Timestamp
+			retained[row.Topic] = row.Value
+		}
+	}
+	return retained, nil
+}
+
+// Throughput of a topic, per second
+type Throughput struct {
+	BytesIn   float64
+	RecordsIn float64
+}
+
+// GetTopicsThroughput returns the bytes-in and records-in rates of the busiest day of the last week of every topic
+func (c *ConfluentCloudMetricsClient) GetTopicsThroughput() (map[string]Throughput, error) {
+	throughput := make(map[string]Throughput)
+	bytesIn, err := c.getDailyPeak(METRICS_RECEIVED_BYTES)
+	if err != nil {
+		return throughput, err
+	}
+	recordsIn, err := c.getDailyPeak(METRICS_RECEIVED_RECORDS)
+	if err != nil {
+		return throughput, err
+	}
+	day := (24 * time.Hour).Seconds()
+	for topic, value := range bytesIn {
+		t := throughput[topic]
+		t.BytesIn = value / day
+		throughput[topic] = t
+	}
+	for topic, value := range recordsIn {
+		t := throughput[topic]
+		t.RecordsIn = value / day
+		throughput[topic] = t
+	}
+	return throughput, nil
+}
+
+// getDailyPeak returns the highest daily value of a topic metric
+func (c *ConfluentCloudMetricsClient) getDailyPeak(metric string) (map[string]float64, error) {
+	peak := make(map[string]float64)
+	responseData, err := c.QueryMetric(metric, METRIC_TOPIC)
+	if err != nil {
+		return peak, err
+	}
+	for _, row := range responseData.Data {
+		if row.Value > peak[row.Topic] {
+			peak[row.Topic] = row.Value
+		}
+	}
+	return peak, nil
+}
+
+// GetTopicDailyRecords returns the received records of a topic per day of the last week, oldest first
+func (c *ConfluentCloudMetricsClient) GetTopicDailyRecords(topic string) ([]float64, error) {
+	responseData, err := c.QueryMetric(METRICS_RECEIVED_RECORDS, METRIC_TOPIC, MetricFilter{Field: METRIC_TOPIC, Op: OPEREATION_EQ, Value: topic})
+	if err != nil {
+		return nil, err
+	}
+	days := make(map[string]float64)
+	for _, row := range responseData.Data {
+		days[row.Timestamp] += row.Value
+	}
+	timestamps := make([]string, 0, len(days))
+	for ts := range days {
+		timestamps = append(timestamps, ts)
+	}
+	sort.Strings(timestamps)
+	series := make([]float64, len(timestamps))
+	for i, ts := range timestamps {
+		series[i] = days[ts]
+	}
+	return series, nil
+}
+
 func getLastWeekRange() string {
 	// Get the current time
 	now := time.Now().UTC()
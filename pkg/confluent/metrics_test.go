@@ -0,0 +1,52 @@
+package confluent
+
+import (
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/fakecloud"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func newTestMetricsClient(t *testing.T, metrics map[string]map[string]float64) *ConfluentCloudMetricsClient {
+	server := httptest.NewServer(fakecloud.NewServer(&fakecloud.Seed{Metrics: metrics}))
+	t.Cleanup(server.Close)
+	client, err := NewConfluentCloudMetricsClient(server.URL, "lkc-1", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestGetRetainedBytesPages(t *testing.T) {
+	// More topics than the limit of a page
+	retained := make(map[string]float64)
+	for i := range LIMIT + 500 {
+		retained[fmt.Sprintf("topic-%04d", i)] = float64(i)
+	}
+	client := newTestMetricsClient(t, map[string]map[string]float64{METRICS_RETAINED_BYTES: retained})
+
+	got, err := client.GetRetainedBytes()
+	if err != nil {
+		t.Fatalf("GetRetainedBytes() error = %v", err)
+	}
+	if len(got) != len(retained) {
+		t.Fatalf("GetRetainedBytes() = %d topics, want %d", len(got), len(retained))
+	}
+	if got["topic-1499"] != 1499 {
+		t.Errorf("GetRetainedBytes()[topic-1499] = %v, want 1499", got["topic-1499"])
+	}
+}
+
+func TestGetTopicDailyRecordsFilters(t *testing.T) {
+	client := newTestMetricsClient(t, map[string]map[string]float64{
+		METRICS_RECEIVED_RECORDS: {"orders": 10, "payments": 20},
+	})
+	got, err := client.GetTopicDailyRecords("payments")
+	if err != nil {
+		t.Fatalf("GetTopicDailyRecords() error = %v", err)
+	}
+	if want := []float64{20}; !slices.Equal(got, want) {
+		t.Errorf("GetTopicDailyRecords() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,460 @@
+package confluent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Typed responses of the Confluent Cloud APIs.
+// Every response is validated after decoding, missing required fields are reported as errors
+// instead of being read with unchecked type assertions.
+// Unknown fields are ignored on purpose: the models only hold the fields the tool reads,
+// and the APIs add fields without a new version, which must not break the cleanup.
+
+// cmk v2 - /cmk/v2/clusters/{id}
+type CmkCluster struct {
+	Id       string             `json:"id"`
+	Metadata CmkClusterMetadata `json:"metadata"`
+	Spec     CmkClusterSpec     `json:"spec"`
+}
+
+// cmk v2 - /cmk/v2/clusters
+type CmkClusterList struct {
+	Data     []CmkCluster `json:"data"`
+	Metadata ListMetadata `json:"metadata"`
+}
+
+func (l CmkClusterList) Validate() error {
+	if l.Data == nil {
+		return fmt.Errorf("clusters: missing data")
+	}
+	for _, c := range l.Data {
+		if c.Id == "" {
+			return fmt.Errorf("clusters: missing id")
+		}
+	}
+	return nil
+}
+
+type CmkClusterMetadata struct {
+	Self         string `json:"self"`
+	ResourceName string `json:"resource_name"`
+}
+
+type CmkClusterSpec struct {
+	DisplayName            string          `json:"display_name"`
+	KafkaBootstrapEndpoint string          `json:"kafka_bootstrap_endpoint"`
+	HttpEndpoint           string          `json:"http_endpoint"`
+	Environment            ObjectReference `json:"environment"`
+}
+
+func (c CmkCluster) Validate() error {
+	if c.Spec.KafkaBootstrapEndpoint == "" {
+		return fmt.Errorf("cmk cluster %s: missing spec.kafka_bootstrap_endpoint", c.Id)
+	}
+	if c.Spec.HttpEndpoint == "" {
+		return fmt.Errorf("cmk cluster %s: missing spec.http_endpoint", c.Id)
+	}
+	if c.Metadata.ResourceName == "" {
+		return fmt.Errorf("cmk cluster %s: missing metadata.resource_name", c.Id)
+	}
+	return nil
+}
+
+type ObjectReference struct {
+	Id           string `json:"id"`
+	Environment  string `json:"environment,omitempty"`
+	Related      string `json:"related,omitempty"`
+	ResourceName string `json:"resource_name,omitempty"`
+	Kind         string `json:"kind,omitempty"`
+}
+
+// iam v2 - /iam/v2/api-keys
+type ApiKeyList struct {
+	Data []ApiKey `json:"data"`
+}
+
+type ApiKey struct {
+	Id   string     `json:"id"`
+	Spec ApiKeySpec `json:"spec"`
+}
+
+type ApiKeySpec struct {
+	DisplayName string          `json:"display_name"`
+	Description string          `json:"description"`
+	Owner       ObjectReference `json:"owner"`
+	Resource    ObjectReference `json:"resource"`
+}
+
+func (k ApiKey) Validate() error {
+	if k.Id == "" {
+		return fmt.Errorf("api key: missing id")
+	}
+	if k.Spec.Owner.Id == "" {
+		return fmt.Errorf("api key %s: missing spec.owner.id", k.Id)
+	}
+	return nil
+}
+
+func (l ApiKeyList) Validate() error {
+	if l.Data == nil {
+		return fmt.Errorf("api keys: missing data")
+	}
+	for _, k := range l.Data {
+		if err := k.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iam v2 - a page of /iam/v2/service-accounts, users, identity-providers or identity-pools
+type IamList struct {
+	Data     []IamResource `json:"data"`
+	Metadata ListMetadata  `json:"metadata"`
+}
+
+// ListMetadata holds the URL of the next page, empty on the last page
+type ListMetadata struct {
+	Next string `json:"next"`
+}
+
+type IamResource struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"display_name"`
+}
+
+func (l IamList) Validate() error {
+	if l.Data == nil {
+		return fmt.Errorf("iam list: missing data")
+	}
+	for _, r := range l.Data {
+		if r.Id == "" {
+			return fmt.Errorf("iam list: missing id")
+		}
+	}
+	return nil
+}
+
+// iam v2 - /iam/v2/role-bindings
+type RoleBindingList struct {
+	Data     []RoleBinding `json:"data"`
+	Metadata ListMetadata  `json:"metadata"`
+}
+
+type RoleBinding struct {
+	Id         string `json:"id"`
+	Principal  string `json:"principal"`
+	RoleName   string `json:"role_name"`
+	CrnPattern string `json:"crn_pattern"`
+}
+
+func (r RoleBinding) Validate() error {
+	if r.Id == "" {
+		return fmt.Errorf("role binding: missing id")
+	}
+	if r.RoleName == "" || r.Principal == "" || r.CrnPattern == "" {
+		return fmt.Errorf("role binding %s: missing principal, role_name or crn_pattern", r.Id)
+	}
+	return nil
+}
+
+func (l RoleBindingList) Validate() error {
+	if l.Data == nil {
+		return fmt.Errorf("role bindings: missing data")
+	}
+	for _, r := range l.Data {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kafka v3 - /kafka/v3/clusters/{id}/topics
+type KafkaTopicList struct {
+	Data []KafkaTopic `json:"data"`
+}
+
+type KafkaTopic struct {
+	ClusterId         string `json:"cluster_id"`
+	TopicName         string `json:"topic_name"`
+	IsInternal        bool   `json:"is_internal"`
+	ReplicationFactor int    `json:"replication_factor"`
+	PartitionsCount   int    `json:"partitions_count"`
+}
+
+func (l KafkaTopicList) Validate() error {
+	if l.Data == nil {
+		return fmt.Errorf("topics: missing data")
+	}
+	for i, t := range l.Data {
+		if t.TopicName == "" {
+			return fmt.Errorf("topics: entry %d: missing topic_name", i)
+		}
+	}
+	return nil
+}
+
+// kafka v3 - /kafka/v3/clusters/{id}/topics/{name}/configs
+type KafkaTopicConfigList struct {
+	Data []KafkaTopicConfig `json:"data"`
+}
+
+type KafkaTopicConfig struct {
+	Name      string  `json:"name"`
+	Value     *string `json:"value"`
+	IsDefault bool    `json:"is_default"`
+	Source    string  `json:"source"`
+}
+
+func (l KafkaTopicConfigList) Validate() error {
+	if l.Data == nil {
+		return fmt.Errorf("topic configs: missing data")
+	}
+	for i, c := range l.Data {
+		if c.Name == "" {
+			return fmt.Errorf("topic configs: entry %d: missing name", i)
+		}
+	}
+	return nil
+}
+
+// kafka v3 - /kafka/v3/clusters/{id}/acls
+type KafkaAclList struct {
+	Data []KafkaAcl `json:"data"`
+}
+
+type KafkaAcl struct {
+	ClusterId    string `json:"cluster_id,omitempty" yaml:"cluster_id,omitempty"`
+	ResourceType string `json:"resource_type" yaml:"resource_type"`
+	ResourceName string `json:"resource_name" yaml:"resource_name"`
+	PatternType  string `json:"pattern_type" yaml:"pattern_type"`
+	Principal    string `json:"principal" yaml:"principal"`
+	Host         string `json:"host" yaml:"host"`
+	Operation    string `json:"operation" yaml:"operation"`
+	Permission   string `json:"permission" yaml:"permission"`
+}
+
+func (l KafkaAclList) Validate() error {
+	if l.Data == nil {
+		return fmt.Errorf("acls: missing data")
+	}
+	return nil
+}
+
+// connect v1 - /connect/v1/environments/{env}/clusters/{id}/connectors?expand=info,status,id
+// The response is a map keyed by connector name.
+type ConnectorExpansionMap map[string]ConnectorExpansion
+
+type ConnectorExpansion struct {
+	Id     ConnectorId     `json:"id"`
+	Info   ConnectorInfo   `json:"info"`
+	Status ConnectorStatus `json:"status"`
+}
+
+type ConnectorId struct {
+	Id     string `json:"id"`
+	IdType string `json:"id_type"`
+}
+
+type ConnectorInfo struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config"`
+}
+
+type ConnectorStatus struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Connector struct {
+		State    string `json:"state"`
+		WorkerId string `json:"worker_id"`
+		Trace    string `json:"trace"`
+	} `json:"connector"`
+}
+
+func (m ConnectorExpansionMap) Validate() error {
+	for name, c := range m {
+		if c.Info.Name == "" {
+			return fmt.Errorf("connector %s: missing info.name", name)
+		}
+		if c.Status.Connector.State == "" {
+			return fmt.Errorf("connector %s: missing status.connector.state", name)
+		}
+	}
+	return nil
+}
+
+// srcm v3 - /srcm/v3/clusters?environment={env}
+type SchemaRegistryClusterList struct {
+	Data []SchemaRegistryCluster `json:"data"`
+}
+
+type SchemaRegistryCluster struct {
+	Id   string                    `json:"id"`
+	Spec SchemaRegistryClusterSpec `json:"spec"`
+}
+
+type SchemaRegistryClusterSpec struct {
+	HttpEndpoint string `json:"http_endpoint"`
+}
+
+func (l SchemaRegistryClusterList) Validate() error {
+	if len(l.Data) == 0 {
+		return fmt.Errorf("schema registry clusters: no cluster found")
+	}
+	if l.Data[0].Id == "" {
+		return fmt.Errorf("schema registry cluster: missing id")
+	}
+	return nil
+}
+
+// Schema Registry - /schemas?latestOnly=true
+type Schema struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	Id      int    `json:"id"`
+}
+
+func (s Schema) Validate() error {
+	if s.Subject == "" {
+		return fmt.Errorf("schema %d: missing subject", s.Id)
+	}
+	return nil
+}
+
+// catalog v1 - /catalog/v1/entity/type/{type}/name/{qualifiedName}/tags
+type CatalogTag struct {
+	TypeName   string                 `json:"typeName"`
+	EntityName string                 `json:"entityName"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// catalog v1 - /catalog/v1/entity/type/{type}/name/{qualifiedName}/businessmetadata
+type CatalogBusinessMetadata struct {
+	TypeName   string                 `json:"typeName"`
+	EntityName string                 `json:"entityName"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// metrics v2 - /v2/metrics/cloud/query
+type MetricsQueryResponse struct {
+	Data []MetricsDataPoint `json:"data"`
+	Meta MetricsMeta        `json:"meta"`
+}
+
+// MetricsMeta has the token of the next page of a query, empty on the last page
+type MetricsMeta struct {
+	Pagination struct {
+		NextPageToken string `json:"next_page_token"`
+	} `json:"pagination"`
+}
+
+type MetricsDataPoint struct {
+	Timestamp   string  `json:"timestamp"`
+	Value       float64 `json:"value"`
+	Topic       string  `json:"metric.topic"`
+	PrincipalId string  `json:"metric.principal_id"`
+}
+
+func (r MetricsQueryResponse) Validate(group string) error {
+	if r.Data == nil {
+		return fmt.Errorf("metrics: missing data")
+	}
+	for i, p := range r.Data {
+		switch group {
+		case METRIC_TOPIC:
+			if p.Topic == "" {
+				return fmt.Errorf("metrics: data point %d: missing %s", i, METRIC_TOPIC)
+			}
+		case METRIC_PRINCIPAL:
+			if p.PrincipalId == "" {
+				return fmt.Errorf("metrics: data point %d: missing %s", i, METRIC_PRINCIPAL)
+			}
+		}
+	}
+	return nil
+}
+
+// ResourceTransactionalID is the TRANSACTIONAL_ID resource type of the Kafka protocol, the Kafka client doesn't define it
+const ResourceTransactionalID kafka.ResourceType = 5
+
+// ResourceTypeName is the name of a resource type, TRANSACTIONAL_ID included
+func ResourceTypeName(t kafka.ResourceType) string {
+	if t == ResourceTransactionalID {
+		return "TRANSACTIONAL_ID"
+	}
+	return t.String()
+}
+
+// fromACLBinding converts a kafka.ACLBinding into its REST representation
+func fromACLBinding(acl kafka.ACLBinding) KafkaAcl {
+	rType := ResourceTypeName(acl.Type)
+	if acl.Type == kafka.ResourceBroker {
+		rType = "CLUSTER"
+	}
+	return KafkaAcl{
+		ResourceType: rType,
+		ResourceName: acl.Name,
+		PatternType:  acl.ResourcePatternType.String(),
+		Principal:    acl.Principal,
+		Host:         acl.Host,
+		Operation:    acl.Operation.String(),
+		Permission:   acl.PermissionType.String(),
+	}
+}
+
+// toACLBinding parses a REST ACL row into a kafka.ACLBinding
+func (a KafkaAcl) toACLBinding() (kafka.ACLBinding, error) {
+	var acl kafka.ACLBinding
+	rType := a.ResourceType
+	if rType == "CLUSTER" {
+		rType = "BROKER"
+	}
+	resourceType, err := kafka.ResourceTypeFromString(rType)
+	if rType == "TRANSACTIONAL_ID" {
+		resourceType, err = ResourceTransactionalID, nil
+	}
+	if err != nil {
+		return acl, fmt.Errorf("invalid resource type %q: %w", a.ResourceType, err)
+	}
+	patternType, err := kafka.ResourcePatternTypeFromString(a.PatternType)
+	if err != nil {
+		return acl, fmt.Errorf("invalid resource pattern type %q: %w", a.PatternType, err)
+	}
+	operation, err := kafka.ACLOperationFromString(a.Operation)
+	if err != nil {
+		return acl, fmt.Errorf("invalid operation %q: %w", a.Operation, err)
+	}
+	permission, err := kafka.ACLPermissionTypeFromString(a.Permission)
+	if err != nil {
+		return acl, fmt.Errorf("invalid permission %q: %w", a.Permission, err)
+	}
+	return kafka.ACLBinding{
+		Type:                resourceType,
+		Name:                a.ResourceName,
+		ResourcePatternType: patternType,
+		Principal:           a.Principal,
+		Host:                a.Host,
+		Operation:           operation,
+		PermissionType:      permission,
+	}, nil
+}
+
+// DeleteResult is the outcome of the deletion of a resource, Err is nil when it was deleted
+type DeleteResult struct {
+	Id  string
+	Err error
+}
+
+// CreateResult is the outcome of the creation of a resource, Err is nil when it was created
+type CreateResult struct {
+	Id  string
+	Err error
+}
+
+// ErrNoMatchingACL is the result of an ACL deletion matching no ACL, e.g. already deleted
+var ErrNoMatchingACL = errors.New("no matching ACL")
@@ -0,0 +1,263 @@
+package confluent
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Topic config drift status
+	PolicyViolationStatus = "POLICY_VIOLATION"
+	// Finding attributes
+	AttrTopic  = "topic"
+	AttrConfig = "config"
+	AttrValue  = "value"
+	AttrPolicy = "policy"
+	AttrFix    = "fix"
+)
+
+// TopicPolicy is the baseline of the topic configs, loaded from YAML
+type TopicPolicy struct {
+	Policies []TopicPolicyRule `yaml:"policies"`
+}
+
+// TopicPolicyRule applies config rules to the topics matching its patterns
+type TopicPolicyRule struct {
+	Name string `yaml:"name"`
+	// Topics are glob patterns, e.g. dev-*. Default all the topics
+	Topics  []string              `yaml:"topics"`
+	Configs map[string]ConfigRule `yaml:"configs"`
+}
+
+// ConfigRule is a required value, a list of allowed values or a numeric range of a config.
+// In ranges -1 is unlimited, e.g. retention.ms=-1 is above any max.
+type ConfigRule struct {
+	Value   *string  `yaml:"value"`
+	Allowed []string `yaml:"allowed"`
+	Min     *float64 `yaml:"min"`
+	Max     *float64 `yaml:"max"`
+	// Fix is the value set by --fix, default the required value, the first allowed value or the exceeded bound
+	Fix *string `yaml:"fix"`
+}
+
+// LoadTopicPolicy reads a topic policy file
+func LoadTopicPolicy(path string) (*TopicPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policy TopicPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid topic policy %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid topic policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+func (p TopicPolicy) Validate() error {
+	for i, rule := range p.Policies {
+		for _, pattern := range rule.Topics {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("policy %d: topics pattern %q: %w", i, pattern, err)
+			}
+		}
+		for name, c := range rule.Configs {
+			if c.Value == nil && len(c.Allowed) == 0 && c.Min == nil && c.Max == nil {
+				return fmt.Errorf("policy %d: config %s: value, allowed, min or max is required", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Matches checks if the rule applies to a topic
+func (r TopicPolicyRule) Matches(topic string) bool {
+	if len(r.Topics) == 0 {
+		return true
+	}
+	for _, pattern := range r.Topics {
+		if ok, _ := path.Match(pattern, topic); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns the config rules of a topic, a later policy overrides the rule of a config
+func (p TopicPolicy) Rules(topic string) map[string]ConfigRule {
+	rules := make(map[string]ConfigRule)
+	for _, rule := range p.Policies {
+		if rule.Matches(topic) {
+			for name, c := range rule.Configs {
+				rules[name] = c
+			}
+		}
+	}
+	return rules
+}
+
+// Check returns whether a value complies with the rule, and otherwise the fixed value
+func (c ConfigRule) Check(value string) (bool, string) {
+	fix := func(v string) (bool, string) {
+		if c.Fix != nil {
+			return false, *c.Fix
+		}
+		return false, v
+	}
+	if c.Value != nil && value != *c.Value {
+		return fix(*c.Value)
+	}
+	if len(c.Allowed) > 0 && !slices.Contains(c.Allowed, value) {
+		return fix(c.Allowed[0])
+	}
+	if c.Min == nil && c.Max == nil {
+		return true, ""
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		n = math.NaN()
+	}
+	if n == -1 {
+		n = math.Inf(1)
+	}
+	if c.Min != nil && !(n >= *c.Min) {
+		return fix(formatNumber(*c.Min))
+	}
+	if c.Max != nil && !(n <= *c.Max) {
+		return fix(formatNumber(*c.Max))
+	}
+	return true, ""
+}
+
+// String describes the rule, e.g. 3600000..604800000
+func (c ConfigRule) String() string {
+	rules := make([]string, 0)
+	if c.Value != nil {
+		rules = append(rules, "= "+*c.Value)
+	}
+	if len(c.Allowed) > 0 {
+		rules = append(rules, "in "+strings.Join(c.Allowed, ", "))
+	}
+	if c.Min != nil || c.Max != nil {
+		min, max := "", ""
+		if c.Min != nil {
+			min = formatNumber(*c.Min)
+		}
+		if c.Max != nil {
+			max = formatNumber(*c.Max)
+		}
+		rules = append(rules, min+".."+max)
+	}
+	return strings.Join(rules, " and ")
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// TopicConfigCleaner detects topic configs out of a policy and sets them back into the policy
+type TopicConfigCleaner struct {
+	clean  *ConfluentClean
+	policy *TopicPolicy
+}
+
+func (c *ConfluentClean) TopicConfigs(policy *TopicPolicy) *TopicConfigCleaner {
+	return &TopicConfigCleaner{clean: c, policy: policy}
+}
+
+func (t *TopicConfigCleaner) Name() string {
+	return "configs"
+}
+
+func (t *TopicConfigCleaner) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := t.clean.newReport(t.Name())
+	report.StartedAt = time.Now()
+
+	topics, err := t.clean.CloudAPI.GetTopics()
+	if err != nil {
+		return report, err
+	}
+	topics = slices.DeleteFunc(topics, func(topic string) bool { return len(t.policy.Rules(topic)) == 0 || isTemporaryTopic(topic) })
+	if len(topics) == 0 {
+		return report, nil
+	}
+	configs, err := t.clean.CloudAPI.DescribeTopicConfigs(topics)
+	if err != nil {
+		return report, err
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		rules := t.policy.Rules(topic)
+		names := make([]string, 0, len(rules))
+		for name := range rules {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value := configs[topic][name]
+			ok, fix := rules[name].Check(value)
+			if ok {
+				continue
+			}
+			report.Findings = append(report.Findings, cleaner.Finding{
+				Kind:      cleaner.KindTopicConfig,
+				Id:        topic + "/" + name,
+				Status:    PolicyViolationStatus,
+				Candidate: true,
+				Attributes: map[string]string{
+					AttrTopic:  topic,
+					AttrConfig: name,
+					AttrValue:  value,
+					AttrPolicy: rules[name].String(),
+					AttrFix:    fix,
+				},
+			})
+		}
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// Apply sets the fixed values, one IncrementalAlterConfigs per topic
+func (t *TopicConfigCleaner) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	byTopic := make(map[string][]cleaner.Finding)
+	topics := make([]string, 0)
+	for _, f := range plan.Findings {
+		topic := f.Attributes[AttrTopic]
+		if _, ok := byTopic[topic]; !ok {
+			topics = append(topics, topic)
+		}
+		byTopic[topic] = append(byTopic[topic], f)
+	}
+	for _, topic := range topics {
+		set := make(map[string]string)
+		for _, f := range byTopic[topic] {
+			set[f.Attributes[AttrConfig]] = f.Attributes[AttrFix]
+		}
+		err := t.clean.CloudAPI.AlterTopicConfigs(topic, set, nil)
+		for _, f := range byTopic[topic] {
+			result.Items = append(result.Items, cleaner.ItemResult{
+				Kind:       cleaner.KindTopicConfig,
+				Id:         f.Id,
+				Action:     cleaner.ActionAlter,
+				PriorState: map[string]string{f.Attributes[AttrConfig]: f.Attributes[AttrValue]},
+				Err:        err,
+			})
+		}
+	}
+	return result, nil
+}
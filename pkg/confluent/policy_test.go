@@ -0,0 +1,52 @@
+package confluent
+
+import (
+	"testing"
+)
+
+func TestConfigRuleCheck(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n float64) *float64 { return &n }
+	tests := []struct {
+		name    string
+		rule    ConfigRule
+		value   string
+		wantOk  bool
+		wantFix string
+	}{
+		{"other value", ConfigRule{Value: str("delete")}, "compact", false, "delete"},
+		{"not allowed", ConfigRule{Allowed: []string{"lz4", "zstd"}}, "gzip", false, "lz4"},
+		{"in range", ConfigRule{Min: num(3600000), Max: num(604800000)}, "86400000", true, ""},
+		{"below min", ConfigRule{Min: num(3600000)}, "60000", false, "3600000"},
+		{"unlimited above max", ConfigRule{Max: num(604800000)}, "-1", false, "604800000"},
+		{"unlimited above min", ConfigRule{Min: num(3600000)}, "-1", true, ""},
+		{"not a number", ConfigRule{Min: num(1)}, "", false, "1"},
+		{"fix", ConfigRule{Max: num(604800000), Fix: str("86400000")}, "-1", false, "86400000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, fix := tt.rule.Check(tt.value)
+			if ok != tt.wantOk || fix != tt.wantFix {
+				t.Errorf("Check(%q) = %v, %q, want %v, %q", tt.value, ok, fix, tt.wantOk, tt.wantFix)
+			}
+		})
+	}
+}
+
+func TestTopicPolicyRules(t *testing.T) {
+	str := func(s string) *string { return &s }
+	policy := TopicPolicy{Policies: []TopicPolicyRule{
+		{Name: "all", Configs: map[string]ConfigRule{"cleanup.policy": {Value: str("delete")}}},
+		{Name: "dev", Topics: []string{"dev-*"}, Configs: map[string]ConfigRule{"cleanup.policy": {Allowed: []string{"delete", "compact"}}}},
+	}}
+	// A later policy overrides the rule of a config
+	if got := policy.Rules("dev-orders")["cleanup.policy"].String(); got != "in delete, compact" {
+		t.Errorf("Rules(dev-orders) = %s, want in delete, compact", got)
+	}
+	if got := policy.Rules("orders")["cleanup.policy"].String(); got != "= delete" {
+		t.Errorf("Rules(orders) = %s, want = delete", got)
+	}
+	if err := (TopicPolicy{Policies: []TopicPolicyRule{{Configs: map[string]ConfigRule{"cleanup.policy": {}}}}}).Validate(); err == nil {
+		t.Error("Validate() of an empty config rule error = nil")
+	}
+}
@@ -0,0 +1,348 @@
+package confluent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"mcolomer/cloud-keeping/pkg/state"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const (
+	RETENTION_MS    = "retention.ms"
+	RETENTION_BYTES = "retention.bytes"
+	// Quarantined topic status
+	QuarantinedStatus = "QUARANTINED"
+	// Finding attributes
+	AttrQuarantinedAt = "quarantined_at"
+	// AttrRemainingAccess are the User:* ACLs and the role bindings of a topic, a quarantine doesn't deny them
+	AttrRemainingAccess = "remaining_access"
+)
+
+// QuarantineConfigs are set on quarantined topics: 1 hour and 1 MiB per partition
+var QuarantineConfigs = map[string]string{
+	RETENTION_MS:    "3600000",
+	RETENTION_BYTES: "1048576",
+}
+
+// QuarantinedTopic is the state of a topic before its quarantine
+type QuarantinedTopic struct {
+	Environment   string    `json:"environment"`
+	Cluster       string    `json:"cluster"`
+	Topic         string    `json:"topic"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+	// Configs are the original values of the quarantine configs, a missing config was the default
+	Configs map[string]string `json:"configs"`
+	// Acls are the ACLs of the topic when it was quarantined
+	Acls []KafkaAcl `json:"acls"`
+	// DenyAcls are the ACLs added by the quarantine
+	DenyAcls []KafkaAcl `json:"deny_acls"`
+}
+
+// QuarantineStore keeps the quarantined topics
+type QuarantineStore = state.Store[QuarantinedTopic]
+
+func NewQuarantineStore(path string) *QuarantineStore {
+	return state.NewStore[QuarantinedTopic](path)
+}
+
+// DefaultQuarantinePath is ~/.cleanup/quarantine.json
+func DefaultQuarantinePath() string {
+	return state.DefaultPath("quarantine.json")
+}
+
+func quarantineKey(environment, cluster, topic string) string {
+	return fmt.Sprintf("%s/%s/%s", environment, cluster, topic)
+}
+
+// Quarantine makes the cleaner quarantine the topics instead of deleting them.
+// Every principal with ALLOW ACLs on a quarantined topic gets a DENY ACL on it, but the admin principals
+// and the owner of the cluster API KEY, which restores the topic.
+// User:* ACLs and role bindings keep their access, the scan reports them as remaining access.
+func (t *TopicCleaner) Quarantine(store *QuarantineStore, admins []string) *TopicCleaner {
+	t.quarantine = store
+	t.admins = admins
+	return t
+}
+
+// markQuarantined flags the quarantined topics, they are no longer candidates
+func (t *TopicCleaner) markQuarantined(report *cleaner.Report) error {
+	records, err := t.quarantine.Load()
+	if err != nil {
+		return fmt.Errorf("loading quarantine state %s: %w", t.quarantine.Path, err)
+	}
+	for i, f := range report.Findings {
+		if _, ok := records[quarantineKey(report.Environment, report.Cluster, f.Id)]; ok {
+			report.Findings[i].Status = QuarantinedStatus
+			report.Findings[i].Candidate = false
+		}
+	}
+	return nil
+}
+
+// markRemainingAccess reports the access to the candidates a quarantine doesn't deny: a DENY of User:* would
+// deny the admins too, and the principals of the role bindings of the topic have no ACL to deny.
+func (t *TopicCleaner) markRemainingAccess(report *cleaner.Report) error {
+	if len(report.Candidates()) == 0 {
+		return nil
+	}
+	roleBindingsCh := commons.AsyncCall(func() ([]ConfluentCloudRoleBinding, error) {
+		return t.clean.CloudAPI.ListRoleBindings(t.clean.CloudAPI.EnvironmentCrn() + "/*")
+	})
+	acls, err := t.clean.CloudAPI.GetACLs()
+	if err != nil {
+		return err
+	}
+	roleBindings := <-roleBindingsCh
+	if isForbidden(roleBindings.Err) {
+		report.Warnings = append(report.Warnings, "The Cloud API KEY can't list the role bindings, the access of the role bindings to the quarantined topics is not checked")
+	} else if roleBindings.Err != nil {
+		return roleBindings.Err
+	}
+	for i, f := range report.Findings {
+		if !f.Candidate {
+			continue
+		}
+		access := remainingAccess(f.Id, t.clean.CloudAPI.ClusterID, acls, roleBindings.Result)
+		if len(access) == 0 {
+			continue
+		}
+		if report.Findings[i].Attributes == nil {
+			report.Findings[i].Attributes = make(map[string]string)
+		}
+		report.Findings[i].Attributes[AttrRemainingAccess] = strings.Join(access, ", ")
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Topic %s stays accessible after its quarantine to %s", f.Id, strings.Join(access, ", ")))
+	}
+	return nil
+}
+
+// remainingAccess returns the User:* ALLOW ACLs and the role bindings of the topic, e.g. User:sa-1 (DeveloperRead)
+func remainingAccess(topic, cluster string, acls []kafka.ACLBinding, roleBindings []ConfluentCloudRoleBinding) []string {
+	access := make([]string, 0)
+	for _, acl := range acls {
+		if acl.PermissionType == kafka.ACLPermissionTypeAllow && acl.Principal == "User:*" && aclMatchesTopic(acl, topic) {
+			access = append(access, "User:* (ACL)")
+			break
+		}
+	}
+	for _, rb := range roleBindings {
+		crn := ParseCRN(rb.Resource)
+		if crn.Cluster() != cluster || crn.Topic == "" {
+			continue
+		}
+		name, prefixed := namePattern(crn.Topic)
+		if name == topic || (prefixed && strings.HasPrefix(topic, name)) {
+			access = append(access, fmt.Sprintf("%s (%s)", rb.Principal, rb.Role))
+		}
+	}
+	return access
+}
+
+func (t *TopicCleaner) quarantineTopics(plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	acls, err := t.clean.CloudAPI.GetACLs()
+	if err != nil {
+		return result, err
+	}
+	// The cluster API KEY owner is never denied, unquarantine needs its ACLs on the topic
+	owner, err := t.clean.ClusterOperator()
+	if err != nil {
+		return result, fmt.Errorf("getting the owner of the cluster API KEY: %w", err)
+	}
+	admins := append(slices.Clone(t.admins), "User:"+owner)
+	for _, f := range plan.Findings {
+		item := cleaner.ItemResult{Kind: cleaner.KindTopic, Id: f.Id, Action: cleaner.ActionQuarantine}
+		record, err := t.quarantineTopic(f.Id, acls, denyPrincipals(acls, f.Id, admins))
+		if record != nil {
+			item.PriorState = record
+		}
+		item.Err = err
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+func (t *TopicCleaner) quarantineTopic(topic string, acls []kafka.ACLBinding, principals []string) (*QuarantinedTopic, error) {
+	key := quarantineKey(t.clean.CloudAPI.Environment, t.clean.CloudAPI.ClusterID, topic)
+	configs, err := t.clean.CloudAPI.KafkaCluster.GetTopicConfigs(topic)
+	if err != nil {
+		return nil, err
+	}
+	record := &QuarantinedTopic{
+		Environment:   t.clean.CloudAPI.Environment,
+		Cluster:       t.clean.CloudAPI.ClusterID,
+		Topic:         topic,
+		QuarantinedAt: time.Now().UTC(),
+		Configs:       make(map[string]string),
+		Acls:          make([]KafkaAcl, 0),
+		DenyAcls:      make([]KafkaAcl, 0),
+	}
+	for name := range QuarantineConfigs {
+		if value, ok := configs[name]; ok {
+			record.Configs[name] = value
+		}
+	}
+	for _, acl := range acls {
+		if aclMatchesTopic(acl, topic) {
+			record.Acls = append(record.Acls, fromACLBinding(acl))
+		}
+	}
+	deny := make([]kafka.ACLBinding, len(principals))
+	for i, principal := range principals {
+		deny[i] = kafka.ACLBinding{
+			Type:                kafka.ResourceTopic,
+			Name:                topic,
+			ResourcePatternType: kafka.ResourcePatternTypeLiteral,
+			Principal:           principal,
+			Host:                "*",
+			Operation:           kafka.ACLOperationAll,
+			PermissionType:      kafka.ACLPermissionTypeDeny,
+		}
+		record.DenyAcls = append(record.DenyAcls, fromACLBinding(deny[i]))
+	}
+
+	// The record is saved first, a partial quarantine can still be restored
+	err = t.quarantine.Update(func(records map[string]QuarantinedTopic) error {
+		if _, ok := records[key]; ok {
+			return fmt.Errorf("topic %s is already quarantined", topic)
+		}
+		records[key] = *record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := t.clean.CloudAPI.AlterTopicConfigs(topic, QuarantineConfigs, nil); err != nil {
+		return record, err
+	}
+	return record, t.clean.CloudAPI.CreateACLs(deny)
+}
+
+// denyPrincipals returns the principals with ALLOW ACLs on the topic, but the admins and the wildcard principal
+func denyPrincipals(acls []kafka.ACLBinding, topic string, admins []string) []string {
+	principals := make([]string, 0)
+	for _, acl := range acls {
+		if acl.PermissionType != kafka.ACLPermissionTypeAllow || acl.Principal == "User:*" || !aclMatchesTopic(acl, topic) {
+			continue
+		}
+		if slices.Contains(admins, acl.Principal) || slices.Contains(principals, acl.Principal) {
+			continue
+		}
+		principals = append(principals, acl.Principal)
+	}
+	sort.Strings(principals)
+	return principals
+}
+
+// aclMatchesTopic checks if a TOPIC ACL applies to the topic
+func aclMatchesTopic(acl kafka.ACLBinding, topic string) bool {
+	if acl.Type != kafka.ResourceTopic {
+		return false
+	}
+	switch acl.ResourcePatternType {
+	case kafka.ResourcePatternTypeLiteral:
+		return acl.Name == topic || acl.Name == "*"
+	case kafka.ResourcePatternTypePrefixed:
+		return strings.HasPrefix(topic, acl.Name)
+	}
+	return false
+}
+
+// QuarantineRestorer restores the configs and removes the DENY ACLs of quarantined topics
+type QuarantineRestorer struct {
+	clean  *ConfluentClean
+	store  *QuarantineStore
+	topics []string
+}
+
+// Unquarantine restores the quarantined topics of the cluster, all of them if no topic is given
+func (c *ConfluentClean) Unquarantine(store *QuarantineStore, topics ...string) *QuarantineRestorer {
+	return &QuarantineRestorer{clean: c, store: store, topics: topics}
+}
+
+func (r *QuarantineRestorer) Name() string {
+	return "unquarantine"
+}
+
+// Restores lifts a quarantine on the first run, whatever the grace period
+func (r *QuarantineRestorer) Restores() {}
+
+func (r *QuarantineRestorer) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := r.clean.newReport(r.Name())
+	report.StartedAt = time.Now()
+	records, err := r.store.Load()
+	if err != nil {
+		return report, fmt.Errorf("loading quarantine state %s: %w", r.store.Path, err)
+	}
+	for _, record := range records {
+		if record.Environment != report.Environment || record.Cluster != report.Cluster {
+			continue
+		}
+		if len(r.topics) > 0 && !slices.Contains(r.topics, record.Topic) {
+			continue
+		}
+		report.Findings = append(report.Findings, cleaner.Finding{
+			Kind:       cleaner.KindTopic,
+			Id:         record.Topic,
+			Status:     QuarantinedStatus,
+			Candidate:  true,
+			Attributes: map[string]string{AttrQuarantinedAt: record.QuarantinedAt.Format(time.RFC3339)},
+			Resource:   record,
+		})
+	}
+	sort.Slice(report.Findings, func(i, j int) bool { return report.Findings[i].Id < report.Findings[j].Id })
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func (r *QuarantineRestorer) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	for _, f := range plan.Findings {
+		record, ok := f.Resource.(QuarantinedTopic)
+		if !ok {
+			continue
+		}
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:       cleaner.KindTopic,
+			Id:         f.Id,
+			Action:     cleaner.ActionRestore,
+			PriorState: record,
+			Err:        r.restore(record),
+		})
+	}
+	return result, nil
+}
+
+// restore deletes the DENY ACLs first, the configs can't be altered while they deny the cleanup
+func (r *QuarantineRestorer) restore(record QuarantinedTopic) error {
+	deny := make([]kafka.ACLBinding, 0, len(record.DenyAcls))
+	for _, acl := range record.DenyAcls {
+		binding, err := acl.toACLBinding()
+		if err != nil {
+			return err
+		}
+		deny = append(deny, binding)
+	}
+	if len(deny) > 0 {
+		// A DENY ACL already deleted is not an error
+		for _, deleted := range r.clean.CloudAPI.DeleteACLs(deny) {
+			if deleted.Err != nil && !errors.Is(deleted.Err, ErrNoMatchingACL) {
+				return fmt.Errorf("deleting DENY ACLs of topic %s: %w", record.Topic, deleted.Err)
+			}
+		}
+	}
+	if err := r.clean.revertTopicConfigs(record.Topic, QuarantineConfigs, record.Configs); err != nil {
+		return err
+	}
+	return r.store.Update(func(records map[string]QuarantinedTopic) error {
+		delete(records, quarantineKey(record.Environment, record.Cluster, record.Topic))
+		return nil
+	})
+}
@@ -0,0 +1,34 @@
+package confluent
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func TestQuarantineAccess(t *testing.T) {
+	acls := []kafka.ACLBinding{
+		testACL("orders", literal, kafka.ACLOperationRead),
+		testACL("ord", prefixed, kafka.ACLOperationRead, principal("User:*")),
+		testACL("orders", literal, kafka.ACLOperationWrite, principal("User:sa-admin")),
+		testACL("orders", literal, kafka.ACLOperationAll, principal("User:sa-2"), deny),
+	}
+	if got, want := denyPrincipals(acls, "orders", []string{"User:sa-admin"}), []string{"User:sa-1"}; !slices.Equal(got, want) {
+		t.Errorf("denyPrincipals() = %v, want %v", got, want)
+	}
+
+	crn := "crn://confluent.cloud/organization=o-1/environment=env-1/cloud-cluster=lkc-1/kafka=lkc-1/topic="
+	roleBindings := []ConfluentCloudRoleBinding{
+		{Role: "DeveloperRead", Resource: crn + "ord*", Principal: "User:sa-3"},
+		{Role: "DeveloperWrite", Resource: crn + "payments", Principal: "User:sa-4"},
+		{Role: "CloudClusterAdmin", Resource: "crn://confluent.cloud/organization=o-1/environment=env-1/cloud-cluster=lkc-1", Principal: "User:sa-5"},
+	}
+	want := []string{"User:* (ACL)", "User:sa-3 (DeveloperRead)"}
+	if got := remainingAccess("orders", "lkc-1", acls, roleBindings); !slices.Equal(got, want) {
+		t.Errorf("remainingAccess() = %v, want %v", got, want)
+	}
+	if got := remainingAccess("orders", "lkc-2", acls[:1], roleBindings); len(got) != 0 {
+		t.Errorf("remainingAccess() of another cluster = %v, want none", got)
+	}
+}
@@ -0,0 +1,167 @@
+package confluent
+
+import (
+	"context"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const (
+	// Redundant ACL status
+	ShadowedStatus   = "SHADOWED"
+	SupersededStatus = "SUPERSEDED"
+	// Finding attributes
+	AttrCoveredBy = "covered_by"
+)
+
+// impliedOperations are the operations granted by an ALLOW of another operation, as Kafka does:
+// READ, WRITE, DELETE and ALTER imply DESCRIBE, ALTER_CONFIGS implies DESCRIBE_CONFIGS
+var impliedOperations = map[kafka.ACLOperation][]kafka.ACLOperation{
+	kafka.ACLOperationDescribe:        {kafka.ACLOperationRead, kafka.ACLOperationWrite, kafka.ACLOperationDelete, kafka.ACLOperationAlter},
+	kafka.ACLOperationDescribeConfigs: {kafka.ACLOperationAlterConfigs},
+}
+
+// RedundantACLCleaner detects the ACLs covered by a broader ACL of the same principal:
+// bindings of a resource covered by a PREFIXED or * binding (SHADOWED),
+// and bindings of an operation covered by ALL or by an implying operation (SUPERSEDED).
+// The ACTIVE bindings are the minimal equivalent set.
+// Kafka keeps the ACLs as a set, identical bindings are listed once: deleting one would delete both.
+type RedundantACLCleaner struct {
+	clean *ConfluentClean
+}
+
+func (c *ConfluentClean) RedundantACLs() *RedundantACLCleaner {
+	return &RedundantACLCleaner{clean: c}
+}
+
+func (r *RedundantACLCleaner) Name() string {
+	return "redundant-acls"
+}
+
+func (r *RedundantACLCleaner) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := r.clean.newReport(r.Name())
+	report.StartedAt = time.Now()
+
+	acls, err := r.clean.CloudAPI.GetACLs()
+	if err != nil {
+		return report, err
+	}
+	acls = uniqueACLs(acls)
+	redundant := make([]bool, len(acls))
+	for i := range acls {
+		_, redundant[i] = redundantWith(acls, i, nil)
+	}
+	for i, acl := range acls {
+		finding := cleaner.Finding{
+			Kind:     cleaner.KindACL,
+			Id:       aclBindingId(acl),
+			Status:   ActiveStatus,
+			Resource: acl,
+		}
+		if j, ok := redundantWith(acls, i, redundant); ok {
+			finding.Status = redundantStatus(acl, acls[j])
+			finding.Candidate = true
+			finding.Attributes = map[string]string{AttrCoveredBy: aclBindingId(acls[j])}
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// uniqueACLs removes the identical bindings
+func uniqueACLs(acls []kafka.ACLBinding) []kafka.ACLBinding {
+	seen := make(map[string]bool, len(acls))
+	unique := make([]kafka.ACLBinding, 0, len(acls))
+	for _, acl := range acls {
+		if id := aclBindingId(acl); !seen[id] {
+			seen[id] = true
+			unique = append(unique, acl)
+		}
+	}
+	return unique
+}
+
+// redundantWith returns a binding covering the binding i, skipping the redundant ones when known.
+// The bindings must be unique. Bindings covering each other are ordered by index so the first one is kept.
+// Coverage is transitive, so a binding covered by a redundant binding is covered by a kept one too.
+func redundantWith(acls []kafka.ACLBinding, i int, redundant []bool) (int, bool) {
+	for j := range acls {
+		if j == i || (redundant != nil && redundant[j]) || !aclCovers(acls[j], acls[i]) {
+			continue
+		}
+		if !aclCovers(acls[i], acls[j]) || j < i {
+			return j, true
+		}
+	}
+	return 0, false
+}
+
+// redundantStatus explains why a binding is covered by a broader one
+func redundantStatus(acl kafka.ACLBinding, by kafka.ACLBinding) string {
+	if acl.Operation != by.Operation {
+		return SupersededStatus
+	}
+	return ShadowedStatus
+}
+
+// aclCovers checks if every request allowed, or denied, by the binding b is allowed, or denied, by the binding a
+func aclCovers(a, b kafka.ACLBinding) bool {
+	return a.Principal == b.Principal &&
+		a.PermissionType == b.PermissionType &&
+		a.Type == b.Type &&
+		(a.Host == "*" || a.Host == b.Host) &&
+		operationCovers(a, b) &&
+		resourceCovers(a, b)
+}
+
+func operationCovers(a, b kafka.ACLBinding) bool {
+	if a.Operation == b.Operation || a.Operation == kafka.ACLOperationAll {
+		return true
+	}
+	// Implied operations are only granted by ALLOW bindings, a DENY of READ doesn't deny DESCRIBE
+	if a.PermissionType != kafka.ACLPermissionTypeAllow {
+		return false
+	}
+	for _, op := range impliedOperations[b.Operation] {
+		if a.Operation == op {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceCovers(a, b kafka.ACLBinding) bool {
+	switch a.ResourcePatternType {
+	case kafka.ResourcePatternTypeLiteral:
+		if a.Name == "*" {
+			return true
+		}
+		return b.ResourcePatternType == kafka.ResourcePatternTypeLiteral && a.Name == b.Name
+	case kafka.ResourcePatternTypePrefixed:
+		if b.ResourcePatternType == kafka.ResourcePatternTypeLiteral && b.Name == "*" {
+			return false
+		}
+		return strings.HasPrefix(b.Name, a.Name)
+	}
+	return false
+}
+
+// MinimalACLs returns the bindings kept by the report of a redundant ACLs scan
+func MinimalACLs(report cleaner.Report) []kafka.ACLBinding {
+	acls := make([]kafka.ACLBinding, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		if acl, ok := f.Resource.(kafka.ACLBinding); ok && !f.Candidate {
+			acls = append(acls, acl)
+		}
+	}
+	return acls
+}
+
+// Apply deletes the redundant bindings
+func (r *RedundantACLCleaner) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	return r.clean.ACLs().Apply(ctx, plan)
+}
@@ -0,0 +1,140 @@
+package confluent
+
+import (
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"slices"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// testACL is an ALLOW ACL of User:sa-1 on any host, modified by the options
+func testACL(name string, pattern kafka.ResourcePatternType, operation kafka.ACLOperation, options ...func(*kafka.ACLBinding)) kafka.ACLBinding {
+	acl := kafka.ACLBinding{
+		Type:                kafka.ResourceTopic,
+		Name:                name,
+		ResourcePatternType: pattern,
+		Principal:           "User:sa-1",
+		Host:                "*",
+		Operation:           operation,
+		PermissionType:      kafka.ACLPermissionTypeAllow,
+	}
+	for _, option := range options {
+		option(&acl)
+	}
+	return acl
+}
+
+func deny(acl *kafka.ACLBinding) { acl.PermissionType = kafka.ACLPermissionTypeDeny }
+
+func principal(p string) func(*kafka.ACLBinding) {
+	return func(acl *kafka.ACLBinding) { acl.Principal = p }
+}
+
+func host(h string) func(*kafka.ACLBinding) {
+	return func(acl *kafka.ACLBinding) { acl.Host = h }
+}
+
+func group(acl *kafka.ACLBinding) { acl.Type = kafka.ResourceGroup }
+
+const (
+	literal  = kafka.ResourcePatternTypeLiteral
+	prefixed = kafka.ResourcePatternTypePrefixed
+)
+
+func TestACLCovers(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b kafka.ACLBinding
+		want bool
+	}{
+		{"identical", testACL("orders", literal, kafka.ACLOperationRead), testACL("orders", literal, kafka.ACLOperationRead), true},
+		{"prefix covers literal", testACL("orders.", prefixed, kafka.ACLOperationRead), testACL("orders.v1", literal, kafka.ACLOperationRead), true},
+		{"prefix covers longer prefix", testACL("orders.", prefixed, kafka.ACLOperationRead), testACL("orders.v1.", prefixed, kafka.ACLOperationRead), true},
+		{"prefix doesn't cover other name", testACL("orders.", prefixed, kafka.ACLOperationRead), testACL("payments", literal, kafka.ACLOperationRead), false},
+		{"literal doesn't cover prefix", testACL("orders.", literal, kafka.ACLOperationRead), testACL("orders.", prefixed, kafka.ACLOperationRead), false},
+		{"prefix doesn't cover *", testACL("", prefixed, kafka.ACLOperationRead), testACL("*", literal, kafka.ACLOperationRead), false},
+		{"* covers literal", testACL("*", literal, kafka.ACLOperationRead), testACL("orders", literal, kafka.ACLOperationRead), true},
+		{"* covers prefix", testACL("*", literal, kafka.ACLOperationRead), testACL("orders.", prefixed, kafka.ACLOperationRead), true},
+		{"ALL covers READ", testACL("orders", literal, kafka.ACLOperationAll), testACL("orders", literal, kafka.ACLOperationRead), true},
+		{"READ doesn't cover ALL", testACL("orders", literal, kafka.ACLOperationRead), testACL("orders", literal, kafka.ACLOperationAll), false},
+		{"READ implies DESCRIBE", testACL("orders", literal, kafka.ACLOperationRead), testACL("orders", literal, kafka.ACLOperationDescribe), true},
+		{"ALTER_CONFIGS implies DESCRIBE_CONFIGS", testACL("orders", literal, kafka.ACLOperationAlterConfigs), testACL("orders", literal, kafka.ACLOperationDescribeConfigs), true},
+		{"DESCRIBE doesn't imply READ", testACL("orders", literal, kafka.ACLOperationDescribe), testACL("orders", literal, kafka.ACLOperationRead), false},
+		{"DENY READ doesn't deny DESCRIBE", testACL("orders", literal, kafka.ACLOperationRead, deny), testACL("orders", literal, kafka.ACLOperationDescribe, deny), false},
+		{"DENY ALL covers DENY READ", testACL("orders", literal, kafka.ACLOperationAll, deny), testACL("orders", literal, kafka.ACLOperationRead, deny), true},
+		{"ALLOW doesn't cover DENY", testACL("orders", literal, kafka.ACLOperationAll), testACL("orders", literal, kafka.ACLOperationRead, deny), false},
+		{"other principal", testACL("orders", literal, kafka.ACLOperationAll), testACL("orders", literal, kafka.ACLOperationRead, principal("User:sa-2")), false},
+		{"other resource type", testACL("orders", literal, kafka.ACLOperationRead), testACL("orders", literal, kafka.ACLOperationRead, group), false},
+		{"any host covers a host", testACL("orders", literal, kafka.ACLOperationRead), testACL("orders", literal, kafka.ACLOperationRead, host("10.0.0.1")), true},
+		{"a host doesn't cover any host", testACL("orders", literal, kafka.ACLOperationRead, host("10.0.0.1")), testACL("orders", literal, kafka.ACLOperationRead), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aclCovers(tt.a, tt.b); got != tt.want {
+				t.Errorf("aclCovers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedundantWith(t *testing.T) {
+	acls := []kafka.ACLBinding{
+		testACL("orders.v1", literal, kafka.ACLOperationDescribe),
+		testACL("orders.v1", literal, kafka.ACLOperationRead),
+		testACL("orders.", prefixed, kafka.ACLOperationRead),
+		testACL("payments", literal, kafka.ACLOperationWrite),
+	}
+	redundant := make([]bool, len(acls))
+	for i := range acls {
+		_, redundant[i] = redundantWith(acls, i, nil)
+	}
+	if want := []bool{true, true, false, false}; !slices.Equal(redundant, want) {
+		t.Fatalf("redundant = %v, want %v", redundant, want)
+	}
+	// Transitivity: the DESCRIBE is covered by the READ, itself redundant, and by the kept PREFIXED READ
+	if j, ok := redundantWith(acls, 0, redundant); !ok || j != 2 {
+		t.Errorf("redundantWith(0) = %d, %v, want the kept binding 2", j, ok)
+	}
+	if _, ok := redundantWith(acls, 2, redundant); ok {
+		t.Errorf("redundantWith(2) is redundant, want kept")
+	}
+}
+
+func TestRedundantWithMutualCoverage(t *testing.T) {
+	// Bindings covering each other keep the first one
+	acls := []kafka.ACLBinding{
+		testACL("*", literal, kafka.ACLOperationAll),
+		testACL("*", literal, kafka.ACLOperationAll),
+	}
+	if _, ok := redundantWith(acls, 0, nil); ok {
+		t.Errorf("first binding is redundant, want kept")
+	}
+	if j, ok := redundantWith(acls, 1, nil); !ok || j != 0 {
+		t.Errorf("redundantWith(1) = %d, %v, want 0", j, ok)
+	}
+}
+
+func TestUniqueACLs(t *testing.T) {
+	acls := []kafka.ACLBinding{
+		testACL("orders", literal, kafka.ACLOperationRead),
+		testACL("orders", literal, kafka.ACLOperationRead),
+		testACL("orders", literal, kafka.ACLOperationWrite),
+	}
+	if got := uniqueACLs(acls); len(got) != 2 {
+		t.Errorf("uniqueACLs() = %d bindings, want 2", len(got))
+	}
+}
+
+func TestMinimalACLs(t *testing.T) {
+	kept := testACL("orders.", prefixed, kafka.ACLOperationRead)
+	report := cleaner.Report{Findings: []cleaner.Finding{
+		{Id: "shadowed", Candidate: true, Resource: testACL("orders.v1", literal, kafka.ACLOperationRead)},
+		{Id: "kept", Resource: kept},
+		{Id: "not an ACL", Resource: "orders"},
+	}}
+	got := MinimalACLs(report)
+	if len(got) != 1 || aclBindingId(got[0]) != aclBindingId(kept) {
+		t.Errorf("MinimalACLs() = %v, want [%v]", got, kept)
+	}
+}
@@ -0,0 +1,364 @@
+package confluent
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/state"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Rightsizing status
+	OverPartitionedStatus = "OVER_PARTITIONED"
+	RightsizedStatus      = "OK"
+	PendingRollbackStatus = "PENDING_ROLLBACK"
+	// Finding attributes
+	AttrRecommendedPartitions = "recommended_partitions"
+	AttrBytesIn               = "bytes_in_per_sec"
+	AttrRecordsIn             = "records_in_per_sec"
+	AttrTemporaryTopic        = "temporary_topic"
+	AttrPhase                 = "phase"
+
+	// Suffix of the temporary topics of a recreation
+	TEMPORARY_TOPIC_SUFFIX = "-rightsize-tmp"
+)
+
+// Phases of a topic recreation
+const (
+	// Copying the records to the temporary topic, the original topic is untouched
+	PhaseCopy = "COPY"
+	// The original topic is deleted, recreated and the records copied back
+	PhaseRecreate = "RECREATE"
+)
+
+// RightsizeOptions are the per partition throughput limits of the recommendations
+type RightsizeOptions struct {
+	// Bytes-in per second a partition should handle
+	PartitionBytesPerSec float64
+	// Records-in per second a partition should handle
+	PartitionRecordsPerSec float64
+	MinPartitions          int
+	// A topic is over-partitioned when it has Factor times the recommended partitions
+	Factor float64
+}
+
+var DefaultRightsizeOptions = RightsizeOptions{
+	PartitionBytesPerSec:   1 << 20,
+	PartitionRecordsPerSec: 1000,
+	MinPartitions:          1,
+	Factor:                 4,
+}
+
+// Recommended is the partition count needed by a throughput
+func (o RightsizeOptions) Recommended(t Throughput) int {
+	partitions := o.MinPartitions
+	if o.PartitionBytesPerSec > 0 {
+		partitions = max(partitions, int(math.Ceil(t.BytesIn/o.PartitionBytesPerSec)))
+	}
+	if o.PartitionRecordsPerSec > 0 {
+		partitions = max(partitions, int(math.Ceil(t.RecordsIn/o.PartitionRecordsPerSec)))
+	}
+	return max(partitions, 1)
+}
+
+// RightsizedTopic is the state of a topic recreation, kept until it completes or is rolled back
+type RightsizedTopic struct {
+	Environment       string            `json:"environment"`
+	Cluster           string            `json:"cluster"`
+	Topic             string            `json:"topic"`
+	TemporaryTopic    string            `json:"temporary_topic"`
+	Partitions        int               `json:"partitions"`
+	TargetPartitions  int               `json:"target_partitions"`
+	ReplicationFactor int               `json:"replication_factor"`
+	Configs           map[string]string `json:"configs"`
+	Phase             string            `json:"phase"`
+	StartedAt         time.Time         `json:"started_at"`
+}
+
+// RightsizeStore keeps the topic recreations in progress
+type RightsizeStore = state.Store[RightsizedTopic]
+
+func NewRightsizeStore(path string) *RightsizeStore {
+	return state.NewStore[RightsizedTopic](path)
+}
+
+// DefaultRightsizePath is ~/.cleanup/rightsize.json
+func DefaultRightsizePath() string {
+	return state.DefaultPath("rightsize.json")
+}
+
+func rightsizeKey(environment, cluster, topic string) string {
+	return fmt.Sprintf("%s/%s/%s", environment, cluster, topic)
+}
+
+// TopicRightsizer detects topics with far more partitions than their throughput needs
+// and recreates them with fewer partitions through a temporary topic
+type TopicRightsizer struct {
+	clean  *ConfluentClean
+	store  *RightsizeStore
+	opts   RightsizeOptions
+	topics []string
+}
+
+// Rightsize checks the topics of the cluster, all of them if no topic is given
+func (c *ConfluentClean) Rightsize(store *RightsizeStore, opts RightsizeOptions, topics ...string) *TopicRightsizer {
+	return &TopicRightsizer{clean: c, store: store, opts: opts, topics: topics}
+}
+
+func (r *TopicRightsizer) Name() string {
+	return "rightsize"
+}
+
+func (r *TopicRightsizer) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := r.clean.newReport(r.Name())
+	report.StartedAt = time.Now()
+
+	topics, err := r.clean.CloudAPI.ListTopics()
+	if err != nil {
+		return report, err
+	}
+	throughput, err := r.clean.MetricsAPI.GetTopicsThroughput()
+	if err != nil {
+		return report, fmt.Errorf("getting topics throughput: %w", err)
+	}
+	records, err := r.store.Load()
+	if err != nil {
+		return report, fmt.Errorf("loading rightsize state %s: %w", r.store.Path, err)
+	}
+	for _, topic := range topics {
+		if len(r.topics) > 0 && !slices.Contains(r.topics, topic.TopicName) {
+			continue
+		}
+		t := throughput[topic.TopicName]
+		recommended := r.opts.Recommended(t)
+		finding := cleaner.Finding{
+			Kind:   cleaner.KindTopic,
+			Id:     topic.TopicName,
+			Status: RightsizedStatus,
+			Attributes: map[string]string{
+				AttrPartitions:            strconv.Itoa(topic.PartitionsCount),
+				AttrRecommendedPartitions: strconv.Itoa(recommended),
+				AttrBytesIn:               strconv.FormatFloat(t.BytesIn, 'f', 0, 64),
+				AttrRecordsIn:             strconv.FormatFloat(t.RecordsIn, 'f', 2, 64),
+			},
+			Resource: topic,
+		}
+		if float64(topic.PartitionsCount) >= r.opts.Factor*float64(recommended) && topic.PartitionsCount > recommended {
+			finding.Status = OverPartitionedStatus
+			finding.Candidate = true
+		}
+		// Topics of an unfinished recreation must be rolled back first
+		_, pending := records[rightsizeKey(report.Environment, report.Cluster, topic.TopicName)]
+		if pending || isTemporaryTopic(topic.TopicName) {
+			finding.Status = PendingRollbackStatus
+			finding.Candidate = false
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func isTemporaryTopic(topic string) bool {
+	return strings.HasSuffix(topic, TEMPORARY_TOPIC_SUFFIX)
+}
+
+func (r *TopicRightsizer) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	for _, f := range plan.Findings {
+		topic, ok := f.Resource.(KafkaTopic)
+		if !ok {
+			continue
+		}
+		recommended, _ := strconv.Atoi(f.Attributes[AttrRecommendedPartitions])
+		record, err := r.recreate(ctx, topic, recommended)
+		item := cleaner.ItemResult{Kind: cleaner.KindTopic, Id: f.Id, Action: cleaner.ActionRecreate, Err: err}
+		if record != nil {
+			item.PriorState = record
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// recreate copies the topic to a temporary topic, recreates it with the target partitions and copies the records back.
+// A failure while copying to the temporary topic leaves the topic untouched, later failures keep the state for a rollback.
+func (r *TopicRightsizer) recreate(ctx context.Context, topic KafkaTopic, partitions int) (*RightsizedTopic, error) {
+	api := r.clean.CloudAPI
+	configs, err := api.KafkaCluster.GetTopicConfigs(topic.TopicName)
+	if err != nil {
+		return nil, err
+	}
+	key := rightsizeKey(api.Environment, api.ClusterID, topic.TopicName)
+	record := &RightsizedTopic{
+		Environment:       api.Environment,
+		Cluster:           api.ClusterID,
+		Topic:             topic.TopicName,
+		TemporaryTopic:    topic.TopicName + TEMPORARY_TOPIC_SUFFIX,
+		Partitions:        topic.PartitionsCount,
+		TargetPartitions:  partitions,
+		ReplicationFactor: topic.ReplicationFactor,
+		Configs:           configs,
+		Phase:             PhaseCopy,
+		StartedAt:         time.Now().UTC(),
+	}
+	err = r.store.Update(func(records map[string]RightsizedTopic) error {
+		if _, ok := records[key]; ok {
+			return fmt.Errorf("topic %s has a recreation in progress, roll it back first", topic.TopicName)
+		}
+		records[key] = *record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The original topic is untouched until the copy completes
+	err = api.CreateTopic(record.TemporaryTopic, partitions, record.ReplicationFactor, configs)
+	if err == nil {
+		_, err = api.KafkaCluster.CopyTopic(ctx, record.Topic, record.TemporaryTopic)
+	}
+	if err != nil {
+		return record, r.abort(record, err)
+	}
+
+	record.Phase = PhaseRecreate
+	err = r.store.Update(func(records map[string]RightsizedTopic) error {
+		records[key] = *record
+		return nil
+	})
+	if err != nil {
+		return record, err
+	}
+	if err := api.DeleteTopic(record.Topic); err != nil {
+		return record, r.pending(record, err)
+	}
+	if err := api.CreateTopic(record.Topic, partitions, record.ReplicationFactor, configs); err != nil {
+		return record, r.pending(record, err)
+	}
+	if _, err := api.KafkaCluster.CopyTopic(ctx, record.TemporaryTopic, record.Topic); err != nil {
+		return record, r.pending(record, err)
+	}
+	if err := api.DeleteTopic(record.TemporaryTopic); err != nil {
+		return record, r.pending(record, err)
+	}
+	return record, removeRightsized(r.store, *record)
+}
+
+// abort deletes the temporary topic of a failed copy
+func (r *TopicRightsizer) abort(record *RightsizedTopic, cause error) error {
+	if err := r.clean.CloudAPI.DeleteTopic(record.TemporaryTopic); err != nil {
+		return r.pending(record, fmt.Errorf("%w, deleting temporary topic: %v", cause, err))
+	}
+	if err := removeRightsized(r.store, *record); err != nil {
+		return fmt.Errorf("%w: %v", cause, err)
+	}
+	return fmt.Errorf("topic %s not changed: %w", record.Topic, cause)
+}
+
+func (r *TopicRightsizer) pending(record *RightsizedTopic, cause error) error {
+	return fmt.Errorf("%w, roll back with: cleanup confluent topics rightsize --rollback %s", cause, record.Topic)
+}
+
+func removeRightsized(store *RightsizeStore, record RightsizedTopic) error {
+	return store.Update(func(records map[string]RightsizedTopic) error {
+		delete(records, rightsizeKey(record.Environment, record.Cluster, record.Topic))
+		return nil
+	})
+}
+
+// RightsizeRollback restores the topics of unfinished recreations with their original partitions
+type RightsizeRollback struct {
+	clean  *ConfluentClean
+	store  *RightsizeStore
+	topics []string
+}
+
+// RightsizeRollback rolls back the unfinished recreations of the cluster, all of them if no topic is given
+func (c *ConfluentClean) RightsizeRollback(store *RightsizeStore, topics ...string) *RightsizeRollback {
+	return &RightsizeRollback{clean: c, store: store, topics: topics}
+}
+
+func (r *RightsizeRollback) Name() string {
+	return "rightsize-rollback"
+}
+
+// Restores rolls back on the first run, whatever the grace period
+func (r *RightsizeRollback) Restores() {}
+
+func (r *RightsizeRollback) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := r.clean.newReport(r.Name())
+	report.StartedAt = time.Now()
+	records, err := r.store.Load()
+	if err != nil {
+		return report, fmt.Errorf("loading rightsize state %s: %w", r.store.Path, err)
+	}
+	for _, record := range records {
+		if record.Environment != report.Environment || record.Cluster != report.Cluster {
+			continue
+		}
+		if len(r.topics) > 0 && !slices.Contains(r.topics, record.Topic) {
+			continue
+		}
+		report.Findings = append(report.Findings, cleaner.Finding{
+			Kind:      cleaner.KindTopic,
+			Id:        record.Topic,
+			Status:    PendingRollbackStatus,
+			Candidate: true,
+			Attributes: map[string]string{
+				AttrPartitions:     strconv.Itoa(record.Partitions),
+				AttrTemporaryTopic: record.TemporaryTopic,
+				AttrPhase:          record.Phase,
+			},
+			Resource: record,
+		})
+	}
+	sort.Slice(report.Findings, func(i, j int) bool { return report.Findings[i].Id < report.Findings[j].Id })
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func (r *RightsizeRollback) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	for _, f := range plan.Findings {
+		record, ok := f.Resource.(RightsizedTopic)
+		if !ok {
+			continue
+		}
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:       cleaner.KindTopic,
+			Id:         f.Id,
+			Action:     cleaner.ActionRestore,
+			PriorState: record,
+			Err:        r.rollback(ctx, record),
+		})
+	}
+	return result, nil
+}
+
+// rollback recreates the topic with its original partitions and configs from the temporary topic.
+// Before the original topic was deleted only the temporary topic is deleted.
+func (r *RightsizeRollback) rollback(ctx context.Context, record RightsizedTopic) error {
+	api := r.clean.CloudAPI
+	if record.Phase == PhaseRecreate {
+		if err := api.DeleteTopic(record.Topic); err != nil {
+			return err
+		}
+		if err := api.CreateTopic(record.Topic, record.Partitions, record.ReplicationFactor, record.Configs); err != nil {
+			return err
+		}
+		if _, err := api.KafkaCluster.CopyTopic(ctx, record.TemporaryTopic, record.Topic); err != nil {
+			return err
+		}
+	}
+	if err := api.DeleteTopic(record.TemporaryTopic); err != nil {
+		return err
+	}
+	return removeRightsized(r.store, record)
+}
@@ -0,0 +1,149 @@
+package confluent
+
+import (
+	"context"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"time"
+)
+
+// RoleBindingCleaner detects the role bindings of the environment whose cluster, or whose topic, consumer group,
+// connector or subject, doesn't exist. Only the topics, consumer groups and connectors of the cluster are checked,
+// and the subjects when the Schema Registry credentials are set.
+type RoleBindingCleaner struct {
+	clean *ConfluentClean
+}
+
+func (c *ConfluentClean) RoleBindings() *RoleBindingCleaner {
+	return &RoleBindingCleaner{clean: c}
+}
+
+func (r *RoleBindingCleaner) Name() string {
+	return "role-bindings"
+}
+
+// liveResources are the existing resources the role bindings are checked against
+type liveResources struct {
+	clusters   map[string]bool
+	topics     []string
+	groups     []string
+	connectors []string
+	// subjects is nil without Schema Registry credentials
+	subjects []string
+}
+
+func (r *RoleBindingCleaner) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := r.clean.newReport(r.Name())
+	report.StartedAt = time.Now()
+
+	roleBindingsCh := commons.AsyncCall(func() ([]ConfluentCloudRoleBinding, error) {
+		return r.clean.CloudAPI.ListRoleBindings(r.clean.CloudAPI.EnvironmentCrn() + "/*")
+	})
+	clustersCh := commons.AsyncCall(func() (map[string]bool, error) {
+		return r.clean.CloudAPI.GetKafkaClusters()
+	})
+	topicsCh := commons.AsyncCall(func() ([]string, error) {
+		return r.clean.CloudAPI.GetTopics()
+	})
+	groupsCh := commons.AsyncCall(func() ([]string, error) {
+		return r.clean.CloudAPI.GetConsumerGroups()
+	})
+	connectorsCh := commons.AsyncCall(func() ([]string, error) {
+		connectors, err := r.clean.CloudAPI.GetConnectors()
+		names := make([]string, len(connectors))
+		for i, connector := range connectors {
+			names[i] = connector.Name
+		}
+		return names, err
+	})
+
+	var live liveResources
+	if r.clean.Catalog != nil {
+		subjects, err := r.clean.Catalog.GetSubjects()
+		if err != nil {
+			return report, err
+		}
+		live.subjects = subjects
+	}
+	roleBindings := <-roleBindingsCh
+	clusters := <-clustersCh
+	topics := <-topicsCh
+	groups := <-groupsCh
+	connectors := <-connectorsCh
+
+	for _, err := range []error{roleBindings.Err, clusters.Err, topics.Err, groups.Err, connectors.Err} {
+		if err != nil {
+			return report, err
+		}
+	}
+	live.clusters, live.topics, live.groups, live.connectors = clusters.Result, topics.Result, groups.Result, connectors.Result
+
+	for _, rb := range roleBindings.Result {
+		status := r.status(ParseCRN(rb.Resource), live)
+		report.Findings = append(report.Findings, cleaner.Finding{
+			Kind:      cleaner.KindRoleBinding,
+			Id:        rb.Id,
+			Status:    status,
+			Candidate: status != ActiveStatus,
+			Resource:  rb,
+		})
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// status checks the target of a role binding, a name ending with * is a prefix
+func (r *RoleBindingCleaner) status(crn CRN, live liveResources) string {
+	if cluster := crn.Cluster(); cluster != "" && cluster != "*" {
+		if !live.clusters[cluster] {
+			return ClusterNotFoundStatus
+		}
+		// The resources of the other clusters of the environment are not checked
+		if cluster != r.clean.CloudAPI.ClusterID {
+			return ActiveStatus
+		}
+		switch {
+		case crn.Topic != "":
+			name, prefixed := namePattern(crn.Topic)
+			return nameStatus(name, prefixed, live.topics, TopicNotFoundStatus, TopicPrefixNotFoundStatus)
+		case crn.Group != "":
+			name, prefixed := namePattern(crn.Group)
+			return nameStatus(name, prefixed, live.groups, GroupNotFoundStatus, GroupPrefixNotFoundStatus)
+		case crn.Connector != "":
+			name, prefixed := namePattern(crn.Connector)
+			return nameStatus(name, prefixed, live.connectors, ConnectorNotFoundStatus, ConnectorPrefixNotFoundStatus)
+		}
+		return ActiveStatus
+	}
+	if crn.Subject != "" && live.subjects != nil && crn.SchemaRegistry == r.clean.Catalog.ClusterID {
+		name, prefixed := namePattern(crn.Subject)
+		return nameStatus(name, prefixed, live.subjects, SubjectNotFoundStatus, SubjectPrefixNotFoundStatus)
+	}
+	return ActiveStatus
+}
+
+func (r *RoleBindingCleaner) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	roleBindings := make([]ConfluentCloudRoleBinding, 0, len(plan.Findings))
+	ids := make([]string, 0, len(plan.Findings))
+	for _, f := range plan.Findings {
+		if rb, ok := f.Resource.(ConfluentCloudRoleBinding); ok {
+			roleBindings = append(roleBindings, rb)
+			ids = append(ids, rb.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	for i, deleted := range r.clean.CloudAPI.DeleteRoleBindings(ids) {
+		rb := roleBindings[i]
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:       cleaner.KindRoleBinding,
+			Id:         deleted.Id,
+			Action:     cleaner.ActionDelete,
+			PriorState: RoleBinding{Id: rb.Id, Principal: rb.Principal, RoleName: rb.Role, CrnPattern: rb.Resource},
+			Err:        deleted.Err,
+		})
+	}
+	return result, nil
+}
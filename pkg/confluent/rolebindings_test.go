@@ -0,0 +1,54 @@
+package confluent
+
+import (
+	"testing"
+)
+
+func TestParseCRN(t *testing.T) {
+	// Unknown parts, e.g. of Flink, are ignored
+	got := ParseCRN("crn://confluent.cloud/organization=o-1/environment=env-1/cloud-cluster=lkc-1/kafka=lkc-1/topic=orders-*/flink-region=aws")
+	want := CRN{Organization: "o-1", Environment: "env-1", CloudCluster: "lkc-1", Kafka: "lkc-1", Topic: "orders-*"}
+	if got != want {
+		t.Errorf("ParseCRN() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRoleBindingStatus(t *testing.T) {
+	r := &RoleBindingCleaner{clean: &ConfluentClean{
+		CloudAPI: &ConfluentCloudClient{ClusterID: "lkc-1"},
+		Catalog:  &CatalogClient{ClusterID: "lsrc-1"},
+	}}
+	live := liveResources{
+		clusters: map[string]bool{"lkc-1": true, "lkc-2": true},
+		topics:   []string{"orders"},
+		subjects: []string{"orders-value"},
+	}
+	env := "crn://confluent.cloud/organization=o-1/environment=env-1"
+	tests := []struct {
+		name     string
+		resource string
+		want     string
+	}{
+		{"environment", env, ActiveStatus},
+		{"deleted cluster", env + "/cloud-cluster=lkc-9/kafka=lkc-9/topic=orders", ClusterNotFoundStatus},
+		{"resource of another cluster", env + "/cloud-cluster=lkc-2/kafka=lkc-2/topic=legacy", ActiveStatus},
+		{"deleted topic", env + "/cloud-cluster=lkc-1/kafka=lkc-1/topic=legacy", TopicNotFoundStatus},
+		{"topic prefix", env + "/cloud-cluster=lkc-1/kafka=lkc-1/topic=ord*", ActiveStatus},
+		{"deleted topic prefix", env + "/cloud-cluster=lkc-1/kafka=lkc-1/topic=legacy-*", TopicPrefixNotFoundStatus},
+		{"deleted connector", env + "/cloud-cluster=lkc-1/connector=sink", ConnectorNotFoundStatus},
+		{"deleted subject", env + "/schema-registry=lsrc-1/subject=legacy-value", SubjectNotFoundStatus},
+		{"subject of another schema registry", env + "/schema-registry=lsrc-2/subject=legacy-value", ActiveStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.status(ParseCRN(tt.resource), live); got != tt.want {
+				t.Errorf("status() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	// Without Schema Registry credentials the subjects are not checked
+	live.subjects = nil
+	if got := r.status(ParseCRN(env+"/schema-registry=lsrc-1/subject=legacy-value"), live); got != ActiveStatus {
+		t.Errorf("status() without subjects = %s, want %s", got, ActiveStatus)
+	}
+}
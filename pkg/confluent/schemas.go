@@ -0,0 +1,113 @@
+package confluent
+
+import (
+	"context"
+	"errors"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Finding attributes
+	AttrVersion = "version"
+
+	// Subjects of the TopicNameStrategy
+	keySuffix   = "-key"
+	valueSuffix = "-value"
+)
+
+// SchemaCleaner detects the subjects of the TopicNameStrategy, <topic>-key and <topic>-value, whose topic doesn't exist.
+// Other subjects, e.g. of the RecordNameStrategy, can't be matched with a topic and are ACTIVE.
+// The subjects are soft deleted, and honour the Stream Catalog tags of their latest version.
+type SchemaCleaner struct {
+	clean *ConfluentClean
+}
+
+func (c *ConfluentClean) Schemas() *SchemaCleaner {
+	return &SchemaCleaner{clean: c}
+}
+
+func (s *SchemaCleaner) Name() string {
+	return "schemas"
+}
+
+func (s *SchemaCleaner) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := s.clean.newReport(s.Name())
+	report.StartedAt = time.Now()
+	if s.clean.Catalog == nil {
+		return report, errors.New("the schemas cleaner needs the Schema Registry API KEY")
+	}
+
+	topicsCh := commons.AsyncCall(func() ([]string, error) {
+		return s.clean.CloudAPI.GetTopics()
+	})
+	schemas, err := s.clean.Catalog.GetLatestSchemas()
+	if err != nil {
+		return report, err
+	}
+	topics := <-topicsCh
+	if topics.Err != nil {
+		return report, topics.Err
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Subject < schemas[j].Subject })
+
+	for _, schema := range schemas {
+		status := subjectStatus(schema.Subject, topics.Result)
+		report.Findings = append(report.Findings, cleaner.Finding{
+			Kind:       cleaner.KindSubject,
+			Id:         schema.Subject,
+			Status:     status,
+			Candidate:  status != ActiveStatus,
+			Attributes: map[string]string{AttrVersion: strconv.Itoa(schema.Version)},
+			Resource:   schema,
+		})
+	}
+	err = s.clean.applyCatalog(&report, EntitySubject, func(f cleaner.Finding) string {
+		// Subjects of the default context, e.g. lsrc-xxxxx:.:orders-value:1
+		return s.clean.Catalog.QualifiedName(".", f.Id, f.Attributes[AttrVersion])
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// subjectStatus matches a subject of the TopicNameStrategy with the topics of the cluster
+func subjectStatus(subject string, topics []string) string {
+	for _, suffix := range []string{keySuffix, valueSuffix} {
+		if topic, ok := strings.CutSuffix(subject, suffix); ok && topic != "" {
+			if slices.Contains(topics, topic) {
+				return ActiveStatus
+			}
+			return TopicNotFoundStatus
+		}
+	}
+	return ActiveStatus
+}
+
+func (s *SchemaCleaner) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	subjects := make([]string, len(plan.Findings))
+	for i, f := range plan.Findings {
+		subjects[i] = f.Id
+	}
+	if len(subjects) == 0 {
+		return result, nil
+	}
+	for i, deleted := range s.clean.CloudAPI.deleteEach("Deleting subjects", subjects, s.clean.Catalog.DeleteSubject) {
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:       cleaner.KindSubject,
+			Id:         deleted.Id,
+			Action:     cleaner.ActionDelete,
+			PriorState: plan.Findings[i].Resource,
+			Err:        deleted.Err,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,24 @@
+package confluent
+
+import "testing"
+
+func TestSubjectStatus(t *testing.T) {
+	topics := []string{"orders", "payments-key"}
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"orders-value", ActiveStatus},
+		{"orders-key", ActiveStatus},
+		{"payments-key-value", ActiveStatus},
+		{"legacy-value", TopicNotFoundStatus},
+		{"legacy-key", TopicNotFoundStatus},
+		{"-value", ActiveStatus},
+		{"com.example.Order", ActiveStatus},
+	}
+	for _, tt := range tests {
+		if got := subjectStatus(tt.subject, topics); got != tt.want {
+			t.Errorf("subjectStatus(%s) = %s, want %s", tt.subject, got, tt.want)
+		}
+	}
+}
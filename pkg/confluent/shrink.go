@@ -0,0 +1,232 @@
+package confluent
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/state"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	SEGMENT_MS    = "segment.ms"
+	SEGMENT_BYTES = "segment.bytes"
+	// Shrunk topic status
+	ShrunkStatus = "SHRUNK"
+	// Finding attributes
+	AttrShrunkAt        = "shrunk_at"
+	AttrShrunkBytes     = "shrunk_bytes"
+	AttrShrunkFromBytes = "shrunk_from_bytes"
+)
+
+// ShrinkConfigs are set on shrunk topics: 1 hour and 1 MiB per partition, in segments of 10 minutes and 50 MiB,
+// the lowest segment settings of Confluent Cloud
+var ShrinkConfigs = map[string]string{
+	RETENTION_MS:    "3600000",
+	RETENTION_BYTES: "1048576",
+	SEGMENT_MS:      "600000",
+	SEGMENT_BYTES:   "52428800",
+}
+
+// ShrunkTopic is the state of a topic before its retention was shrunk
+type ShrunkTopic struct {
+	Environment string    `json:"environment"`
+	Cluster     string    `json:"cluster"`
+	Topic       string    `json:"topic"`
+	ShrunkAt    time.Time `json:"shrunk_at"`
+	// Configs are the original values of the shrink configs, a missing config was the default
+	Configs map[string]string `json:"configs"`
+	// RetainedBytes before the shrink
+	RetainedBytes float64 `json:"retained_bytes"`
+}
+
+// ShrinkStore keeps the shrunk topics
+type ShrinkStore = state.Store[ShrunkTopic]
+
+func NewShrinkStore(path string) *ShrinkStore {
+	return state.NewStore[ShrunkTopic](path)
+}
+
+// DefaultShrinkPath is ~/.cleanup/shrink.json
+func DefaultShrinkPath() string {
+	return state.DefaultPath("shrink.json")
+}
+
+func shrinkKey(environment, cluster, topic string) string {
+	return fmt.Sprintf("%s/%s/%s", environment, cluster, topic)
+}
+
+// Shrink makes the cleaner shrink the retention of the topics instead of deleting them
+func (t *TopicCleaner) Shrink(store *ShrinkStore) *TopicCleaner {
+	t.shrink = store
+	return t
+}
+
+// ShrunkBytes estimates the storage of a topic after the shrink, excluding the active segments: its retention.bytes per partition at most.
+// Kafka never deletes the active segment of a partition, and the active segment of an inactive topic doesn't roll,
+// so up to the former segment.bytes per partition remain on top of it.
+func ShrunkBytes(u TopicUsage) float64 {
+	limit, _ := strconv.ParseFloat(ShrinkConfigs[RETENTION_BYTES], 64)
+	return min(u.RetainedBytes, limit*float64(u.Partitions))
+}
+
+// markShrunk sets the estimated storage of the candidates after the shrink and flags the shrunk topics, they are no longer candidates
+func (t *TopicCleaner) markShrunk(report *cleaner.Report) error {
+	records, err := t.shrink.Load()
+	if err != nil {
+		return fmt.Errorf("loading shrink state %s: %w", t.shrink.Path, err)
+	}
+	for i, f := range report.Findings {
+		if f.Candidate {
+			report.Findings[i].Attributes[AttrShrunkBytes] = strconv.FormatFloat(ShrunkBytes(Usage(f)), 'f', 0, 64)
+		}
+		if record, ok := records[shrinkKey(report.Environment, report.Cluster, f.Id)]; ok {
+			report.Findings[i].Status = ShrunkStatus
+			report.Findings[i].Candidate = false
+			report.Findings[i].Attributes[AttrShrunkAt] = record.ShrunkAt.Format(time.RFC3339)
+		}
+	}
+	return nil
+}
+
+func (t *TopicCleaner) shrinkTopics(plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	for _, f := range plan.Findings {
+		item := cleaner.ItemResult{Kind: cleaner.KindTopic, Id: f.Id, Action: cleaner.ActionShrink}
+		record, err := t.shrinkTopic(f)
+		if record != nil {
+			item.PriorState = record
+		}
+		item.Err = err
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+func (t *TopicCleaner) shrinkTopic(f cleaner.Finding) (*ShrunkTopic, error) {
+	key := shrinkKey(t.clean.CloudAPI.Environment, t.clean.CloudAPI.ClusterID, f.Id)
+	configs, err := t.clean.CloudAPI.KafkaCluster.GetTopicConfigs(f.Id)
+	if err != nil {
+		return nil, err
+	}
+	record := &ShrunkTopic{
+		Environment:   t.clean.CloudAPI.Environment,
+		Cluster:       t.clean.CloudAPI.ClusterID,
+		Topic:         f.Id,
+		ShrunkAt:      time.Now().UTC(),
+		Configs:       make(map[string]string),
+		RetainedBytes: Usage(f).RetainedBytes,
+	}
+	for name := range ShrinkConfigs {
+		if value, ok := configs[name]; ok {
+			record.Configs[name] = value
+		}
+	}
+	// The record is saved first, a failed shrink can still be reverted
+	err = t.shrink.Update(func(records map[string]ShrunkTopic) error {
+		if _, ok := records[key]; ok {
+			return fmt.Errorf("topic %s is already shrunk", f.Id)
+		}
+		records[key] = *record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, t.clean.CloudAPI.AlterTopicConfigs(f.Id, ShrinkConfigs, nil)
+}
+
+// revertTopicConfigs sets the changed configs back to their original values, a config without original value is reset to its default
+func (c *ConfluentClean) revertTopicConfigs(topic string, changed map[string]string, original map[string]string) error {
+	set := make(map[string]string)
+	reset := make([]string, 0)
+	for name := range changed {
+		if value, ok := original[name]; ok {
+			set[name] = value
+		} else {
+			reset = append(reset, name)
+		}
+	}
+	return c.CloudAPI.AlterTopicConfigs(topic, set, reset)
+}
+
+// ShrinkRestorer reverts the retention and segment configs of shrunk topics
+type ShrinkRestorer struct {
+	clean  *ConfluentClean
+	store  *ShrinkStore
+	topics []string
+}
+
+// Unshrink reverts the shrunk topics of the cluster, all of them if no topic is given
+func (c *ConfluentClean) Unshrink(store *ShrinkStore, topics ...string) *ShrinkRestorer {
+	return &ShrinkRestorer{clean: c, store: store, topics: topics}
+}
+
+func (r *ShrinkRestorer) Name() string {
+	return "unshrink"
+}
+
+// Restores reverts the shrunk configs on the first run, whatever the grace period
+func (r *ShrinkRestorer) Restores() {}
+
+func (r *ShrinkRestorer) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := r.clean.newReport(r.Name())
+	report.StartedAt = time.Now()
+	records, err := r.store.Load()
+	if err != nil {
+		return report, fmt.Errorf("loading shrink state %s: %w", r.store.Path, err)
+	}
+	for _, record := range records {
+		if record.Environment != report.Environment || record.Cluster != report.Cluster {
+			continue
+		}
+		if len(r.topics) > 0 && !slices.Contains(r.topics, record.Topic) {
+			continue
+		}
+		report.Findings = append(report.Findings, cleaner.Finding{
+			Kind:      cleaner.KindTopic,
+			Id:        record.Topic,
+			Status:    ShrunkStatus,
+			Candidate: true,
+			Attributes: map[string]string{
+				AttrShrunkAt:        record.ShrunkAt.Format(time.RFC3339),
+				AttrShrunkFromBytes: strconv.FormatFloat(record.RetainedBytes, 'f', 0, 64),
+			},
+			Resource: record,
+		})
+	}
+	sort.Slice(report.Findings, func(i, j int) bool { return report.Findings[i].Id < report.Findings[j].Id })
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func (r *ShrinkRestorer) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	for _, f := range plan.Findings {
+		record, ok := f.Resource.(ShrunkTopic)
+		if !ok {
+			continue
+		}
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:       cleaner.KindTopic,
+			Id:         f.Id,
+			Action:     cleaner.ActionRestore,
+			PriorState: record,
+			Err:        r.restore(record),
+		})
+	}
+	return result, nil
+}
+
+func (r *ShrinkRestorer) restore(record ShrunkTopic) error {
+	if err := r.clean.revertTopicConfigs(record.Topic, ShrinkConfigs, record.Configs); err != nil {
+		return err
+	}
+	return r.store.Update(func(records map[string]ShrunkTopic) error {
+		delete(records, shrinkKey(record.Environment, record.Cluster, record.Topic))
+		return nil
+	})
+}
@@ -0,0 +1,142 @@
+package confluent
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"slices"
+	"time"
+)
+
+// TopicCleaner detects topics without received records in the last 7 days
+type TopicCleaner struct {
+	clean *ConfluentClean
+	// Quarantine instead of delete
+	quarantine *QuarantineStore
+	admins     []string
+	// Shrink the retention instead of delete
+	shrink *ShrinkStore
+}
+
+func (t *TopicCleaner) Name() string {
+	return "topics"
+}
+
+func (t *TopicCleaner) Scan(ctx context.Context) (cleaner.Report, error) {
+	report := t.clean.newReport(t.Name())
+	report.StartedAt = time.Now()
+
+	activeTopicsCh := commons.AsyncCall(func() ([]string, error) {
+		return t.clean.MetricsAPI.GetActiveTopics()
+	})
+
+	retainedCh := commons.AsyncCall(func() (map[string]float64, error) {
+		return t.clean.MetricsAPI.GetRetainedBytes()
+	})
+
+	topicsCh := commons.AsyncCall(func() ([]KafkaTopic, error) {
+		return t.clean.CloudAPI.ListTopics()
+	})
+
+	activeTopics := <-activeTopicsCh
+	retained := <-retainedCh
+	topics := <-topicsCh
+
+	if activeTopics.Err != nil {
+		return report, fmt.Errorf("getting active topics: %w", activeTopics.Err)
+	}
+	if retained.Err != nil {
+		return report, fmt.Errorf("getting retained bytes: %w", retained.Err)
+	}
+	if topics.Err != nil {
+		return report, topics.Err
+	}
+
+	// The temporary topics of unfinished rightsize recreations hold the only copy of the records
+	topics.Result = slices.DeleteFunc(topics.Result, func(topic KafkaTopic) bool { return isTemporaryTopic(topic.TopicName) })
+	names := make([]string, len(topics.Result))
+	for i, topic := range topics.Result {
+		names[i] = topic.TopicName
+	}
+	report.Findings = getInactiveTopics(activeTopics.Result, names)
+	// Storage and partitions that a deletion would reclaim
+	for i, topic := range topics.Result {
+		t.clean.Prices.setUsage(&report.Findings[i], TopicUsage{
+			RetainedBytes: retained.Result[topic.TopicName],
+			Partitions:    topic.PartitionsCount,
+		})
+	}
+	err := t.clean.applyCatalog(&report, EntityTopic, func(f cleaner.Finding) string {
+		return t.clean.Catalog.QualifiedName(t.clean.CloudAPI.ClusterID, f.Id)
+	})
+	if err != nil {
+		return report, err
+	}
+	if t.quarantine != nil {
+		if err := t.markQuarantined(&report); err != nil {
+			return report, err
+		}
+		if err := t.markRemainingAccess(&report); err != nil {
+			return report, err
+		}
+	}
+	if t.shrink != nil {
+		if err := t.markShrunk(&report); err != nil {
+			return report, err
+		}
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func getInactiveTopics(activeTopics []string, topics []string) []cleaner.Finding {
+	findings := make([]cleaner.Finding, len(topics))
+	for i, topic := range topics {
+		findings[i] = cleaner.Finding{
+			Kind:     cleaner.KindTopic,
+			Id:       topic,
+			Status:   ActiveStatus,
+			Resource: topic,
+		}
+		if !slices.Contains(activeTopics, topic) {
+			findings[i].Status = InactiveStatus
+			findings[i].Candidate = true
+		}
+	}
+	return findings
+}
+
+func (t *TopicCleaner) Apply(ctx context.Context, plan cleaner.Plan) (cleaner.Result, error) {
+	result := cleaner.NewResult(plan)
+	if len(plan.Findings) == 0 {
+		return result, nil
+	}
+	if t.quarantine != nil {
+		return t.quarantineTopics(plan)
+	}
+	if t.shrink != nil {
+		return t.shrinkTopics(plan)
+	}
+	topics := make([]string, len(plan.Findings))
+	for i, f := range plan.Findings {
+		topics[i] = f.Id
+	}
+	// Keep the topic configs as prior state, a failure doesn't prevent the deletion
+	configs := make(map[string]map[string]string, len(topics))
+	for _, topic := range topics {
+		if c, err := t.clean.CloudAPI.KafkaCluster.GetTopicConfigs(topic); err == nil {
+			configs[topic] = c
+		}
+	}
+	for _, deleted := range t.clean.CloudAPI.DeleteTopics(topics) {
+		result.Items = append(result.Items, cleaner.ItemResult{
+			Kind:       cleaner.KindTopic,
+			Id:         deleted.Id,
+			Action:     cleaner.ActionDelete,
+			PriorState: configs[deleted.Id],
+			Err:        deleted.Err,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,170 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// CloudEvents types
+const (
+	TypeDetected     = "io.cloudkeeping.cleanup.detected"
+	TypeDeleted      = "io.cloudkeeping.cleanup.deleted"
+	TypeDeleteFailed = "io.cloudkeeping.cleanup.delete_failed"
+
+	// typePrefix and the outcome of an action are the type of the other events, e.g. io.cloudkeeping.cleanup.quarantined
+	typePrefix = "io.cloudkeeping.cleanup."
+
+	SPEC_VERSION = "1.0"
+	CONTENT_TYPE = "application/cloudevents+json"
+
+	flushTimeoutMs = 30000
+)
+
+// CloudEvent is a CloudEvents 1.0 event in JSON structured mode
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	Id              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            EventData `json:"data"`
+}
+
+// EventData is the payload of a cleanup event
+type EventData struct {
+	Operator    string       `json:"operator"`
+	Environment string       `json:"environment"`
+	Cluster     string       `json:"cluster"`
+	Cleaner     string       `json:"cleaner"`
+	Kind        cleaner.Kind `json:"kind"`
+	Resource    string       `json:"resource"`
+	Status      string       `json:"status,omitempty"`
+	Attributes  interface{}  `json:"attributes,omitempty"`
+	PriorState  interface{}  `json:"prior_state,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// Publisher produces a CloudEvent for every decision and deletion to a Kafka topic
+type Publisher struct {
+	producer *kafka.Producer
+	Topic    string
+	Operator string
+}
+
+func NewPublisher(topic string, config *kafka.ConfigMap, operator string) (*Publisher, error) {
+	producer, err := kafka.NewProducer(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating events producer: %w", err)
+	}
+	return &Publisher{producer: producer, Topic: topic, Operator: operator}, nil
+}
+
+// Scanned publishes a detected event for every deletion candidate of a report
+func (p *Publisher) Scanned(report cleaner.Report) error {
+	events := make([]CloudEvent, 0)
+	for _, f := range report.Candidates() {
+		event := p.event(TypeDetected, report.Environment, report.Cluster, report.Cleaner, f.Kind, f.Id)
+		event.Data.Status = f.Status
+		if len(f.Attributes) > 0 {
+			event.Data.Attributes = f.Attributes
+		}
+		events = append(events, event)
+	}
+	return p.publish(events)
+}
+
+// Planned doesn't publish, the plan is built from the detected events
+func (p *Publisher) Planned(plan cleaner.Plan) error {
+	return nil
+}
+
+// Applied publishes an event for every item of a result: deleted, delete_failed, quarantined...
+func (p *Publisher) Applied(result cleaner.Result) error {
+	events := make([]CloudEvent, 0)
+	for _, item := range result.Items {
+		eventType := typePrefix + strings.ToLower(item.Outcome())
+		event := p.event(eventType, result.Environment, result.Cluster, result.Cleaner, item.Kind, item.Id)
+		event.Data.PriorState = item.PriorState
+		if !item.Succeeded() {
+			event.Data.Error = item.Err.Error()
+		}
+		events = append(events, event)
+	}
+	return p.publish(events)
+}
+
+func (p *Publisher) Close() {
+	p.producer.Flush(flushTimeoutMs)
+	p.producer.Close()
+}
+
+func (p *Publisher) event(eventType, environment, cluster, cleanerName string, kind cleaner.Kind, resource string) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     SPEC_VERSION,
+		Id:              newId(),
+		Source:          fmt.Sprintf("/cleanup/confluent/%s/%s", environment, cluster),
+		Type:            eventType,
+		Subject:         fmt.Sprintf("%s/%s", kind, resource),
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data: EventData{
+			Operator:    p.Operator,
+			Environment: environment,
+			Cluster:     cluster,
+			Cleaner:     cleanerName,
+			Kind:        kind,
+			Resource:    resource,
+		},
+	}
+}
+
+// publish produces the events and waits for their delivery
+func (p *Publisher) publish(events []CloudEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	deliveries := make(chan kafka.Event, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		err = p.producer.Produce(&kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &p.Topic, Partition: kafka.PartitionAny},
+			Key:            []byte(event.Subject),
+			Value:          value,
+			Headers:        []kafka.Header{{Key: "content-type", Value: []byte(CONTENT_TYPE)}},
+		}, deliveries)
+		if err != nil {
+			return fmt.Errorf("producing event %s: %w", event.Subject, err)
+		}
+	}
+	timeout := time.After(flushTimeoutMs * time.Millisecond)
+	for range events {
+		select {
+		case e := <-deliveries:
+			if m, ok := e.(*kafka.Message); ok && m.TopicPartition.Error != nil {
+				return fmt.Errorf("delivering event to %s: %w", p.Topic, m.TopicPartition.Error)
+			}
+		case <-timeout:
+			return fmt.Errorf("delivering events to %s: timeout", p.Topic)
+		}
+	}
+	return nil
+}
+
+// newId returns a random CloudEvent id
+func newId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
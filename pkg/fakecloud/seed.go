@@ -0,0 +1,147 @@
+package fakecloud
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Seed describes the Confluent Cloud organization emulated by the fake server.
+type Seed struct {
+	Organization    string                        `yaml:"organization"`
+	Environment     string                        `yaml:"environment"`
+	Clusters        []SeedCluster                 `yaml:"clusters"`
+	ServiceAccounts []SeedServiceAccount          `yaml:"service_accounts"`
+	Users           []SeedUser                    `yaml:"users"`
+	IdentityPools   []SeedIdentityPool            `yaml:"identity_pools"`
+	ApiKeys         []SeedApiKey                  `yaml:"api_keys"`
+	RoleBindings    []SeedRoleBinding             `yaml:"role_bindings"`
+	Metrics         map[string]map[string]float64 `yaml:"metrics"`
+	SchemaRegistry  SeedSchemaRegistry            `yaml:"schema_registry"`
+	Catalog         []SeedCatalogEntity           `yaml:"catalog"`
+}
+
+type SeedCluster struct {
+	Id         string          `yaml:"id"`
+	Name       string          `yaml:"name"`
+	Bootstrap  string          `yaml:"bootstrap"`
+	Topics     []SeedTopic     `yaml:"topics"`
+	Acls       []SeedAcl       `yaml:"acls"`
+	Connectors []SeedConnector `yaml:"connectors"`
+}
+
+type SeedTopic struct {
+	Name              string            `yaml:"name"`
+	Partitions        int               `yaml:"partitions"`
+	ReplicationFactor int               `yaml:"replication_factor"`
+	Configs           map[string]string `yaml:"configs"`
+}
+
+type SeedAcl struct {
+	ResourceType string `yaml:"resource_type" json:"resource_type"`
+	ResourceName string `yaml:"resource_name" json:"resource_name"`
+	PatternType  string `yaml:"pattern_type" json:"pattern_type"`
+	Principal    string `yaml:"principal" json:"principal"`
+	Host         string `yaml:"host" json:"host"`
+	Operation    string `yaml:"operation" json:"operation"`
+	Permission   string `yaml:"permission" json:"permission"`
+}
+
+type SeedConnector struct {
+	Name  string `yaml:"name"`
+	Id    string `yaml:"id"`
+	Type  string `yaml:"type"`
+	State string `yaml:"state"`
+}
+
+type SeedServiceAccount struct {
+	Id          string `yaml:"id"`
+	DisplayName string `yaml:"display_name"`
+}
+
+type SeedUser struct {
+	Id       string `yaml:"id"`
+	Email    string `yaml:"email"`
+	FullName string `yaml:"full_name"`
+}
+
+type SeedIdentityPool struct {
+	Id          string `yaml:"id"`
+	Provider    string `yaml:"provider"`
+	DisplayName string `yaml:"display_name"`
+}
+
+type SeedApiKey struct {
+	Id       string `yaml:"id"`
+	Owner    string `yaml:"owner"`
+	Resource string `yaml:"resource"`
+}
+
+type SeedRoleBinding struct {
+	Id         string `yaml:"id"`
+	Principal  string `yaml:"principal"`
+	RoleName   string `yaml:"role_name"`
+	CrnPattern string `yaml:"crn_pattern"`
+}
+
+type SeedSchemaRegistry struct {
+	Id       string   `yaml:"id"`
+	Subjects []string `yaml:"subjects"`
+}
+
+// SeedCatalogEntity holds the Stream Catalog tags and business metadata of an entity
+type SeedCatalogEntity struct {
+	Type             string             `yaml:"type"`
+	QualifiedName    string             `yaml:"qualified_name"`
+	Tags             []SeedCatalogEntry `yaml:"tags"`
+	BusinessMetadata []SeedCatalogEntry `yaml:"business_metadata"`
+}
+
+type SeedCatalogEntry struct {
+	Name       string            `yaml:"name"`
+	Attributes map[string]string `yaml:"attributes"`
+}
+
+// LoadSeed reads a seed YAML file
+func LoadSeed(path string) (*Seed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var seed Seed
+	if err := yaml.Unmarshal(data, &seed); err != nil {
+		return nil, fmt.Errorf("invalid seed file %s: %w", path, err)
+	}
+	if seed.Organization == "" {
+		seed.Organization = "org-fake"
+	}
+	if seed.Environment == "" {
+		return nil, fmt.Errorf("invalid seed file %s: environment is required", path)
+	}
+	if seed.SchemaRegistry.Id == "" {
+		seed.SchemaRegistry.Id = "lsrc-fake"
+	}
+	for i, c := range seed.Clusters {
+		if c.Id == "" {
+			return nil, fmt.Errorf("invalid seed file %s: cluster %d: id is required", path, i)
+		}
+		if c.Bootstrap == "" {
+			seed.Clusters[i].Bootstrap = "localhost:9092"
+		}
+		for j, t := range c.Topics {
+			if t.Partitions == 0 {
+				seed.Clusters[i].Topics[j].Partitions = 1
+			}
+			if t.ReplicationFactor == 0 {
+				seed.Clusters[i].Topics[j].ReplicationFactor = 3
+			}
+		}
+		for j, a := range c.Acls {
+			if a.Host == "" {
+				seed.Clusters[i].Acls[j].Host = "*"
+			}
+		}
+	}
+	return &seed, nil
+}
@@ -0,0 +1,568 @@
+package fakecloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server emulates the subset of the Confluent Cloud APIs used by the cleaners:
+// cmk v2, iam v2, kafka v3, connect v1, srcm v3, the Stream Catalog and the metrics v2 query endpoint.
+// Every API is served from the same base URL, authentication is not checked.
+type Server struct {
+	mu   sync.Mutex
+	seed *Seed
+	mux  *http.ServeMux
+}
+
+func NewServer(seed *Seed) *Server {
+	s := &Server{seed: seed, mux: http.NewServeMux()}
+	// cmk
+	s.mux.HandleFunc("GET /cmk/v2/clusters", s.listClusters)
+	s.mux.HandleFunc("GET /cmk/v2/clusters/{id}", s.getCluster)
+	// iam
+	s.mux.HandleFunc("GET /iam/v2/api-keys", s.listApiKeys)
+	s.mux.HandleFunc("GET /iam/v2/api-keys/{id}", s.getApiKey)
+	s.mux.HandleFunc("DELETE /iam/v2/api-keys/{id}", s.deleteApiKey)
+	s.mux.HandleFunc("GET /iam/v2/service-accounts", s.listServiceAccounts)
+	s.mux.HandleFunc("GET /iam/v2/users", s.listUsers)
+	s.mux.HandleFunc("GET /iam/v2/identity-providers", s.listIdentityProviders)
+	s.mux.HandleFunc("GET /iam/v2/identity-providers/{id}/identity-pools", s.listIdentityPools)
+	s.mux.HandleFunc("GET /iam/v2/role-bindings", s.listRoleBindings)
+	s.mux.HandleFunc("DELETE /iam/v2/role-bindings/{id}", s.deleteRoleBinding)
+	// kafka v3
+	s.mux.HandleFunc("GET /kafka/v3/clusters/{id}/topics", s.listTopics)
+	s.mux.HandleFunc("DELETE /kafka/v3/clusters/{id}/topics/{name}", s.deleteTopic)
+	s.mux.HandleFunc("GET /kafka/v3/clusters/{id}/topics/{name}/configs", s.listTopicConfigs)
+	s.mux.HandleFunc("GET /kafka/v3/clusters/{id}/acls", s.listAcls)
+	s.mux.HandleFunc("POST /kafka/v3/clusters/{id}/acls", s.createAcl)
+	s.mux.HandleFunc("DELETE /kafka/v3/clusters/{id}/acls", s.deleteAcls)
+	// connect
+	s.mux.HandleFunc("GET /connect/v1/environments/{env}/clusters/{id}/connectors", s.listConnectors)
+	s.mux.HandleFunc("DELETE /connect/v1/environments/{env}/clusters/{id}/connectors/{name}", s.deleteConnector)
+	// schema registry
+	s.mux.HandleFunc("GET /srcm/v3/clusters", s.listSchemaRegistryClusters)
+	s.mux.HandleFunc("GET /subjects", s.listSubjects)
+	s.mux.HandleFunc("DELETE /subjects/{subject}", s.deleteSubject)
+	s.mux.HandleFunc("GET /schemas", s.listSchemas)
+	s.mux.HandleFunc("GET /catalog/v1/entity/type/{type}/name/{name}/tags", s.listCatalogTags)
+	s.mux.HandleFunc("GET /catalog/v1/entity/type/{type}/name/{name}/businessmetadata", s.listCatalogBusinessMetadata)
+	// telemetry
+	s.mux.HandleFunc("POST /v2/metrics/cloud/query", s.queryMetrics)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Printf("%s %s %s\n", time.Now().Format(time.RFC3339), r.Method, r.URL)
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) cluster(id string) *SeedCluster {
+	for i := range s.seed.Clusters {
+		if s.seed.Clusters[i].Id == id {
+			return &s.seed.Clusters[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) crn(cluster string) string {
+	return fmt.Sprintf("crn://confluent.cloud/organization=%s/environment=%s/cloud-cluster=%s", s.seed.Organization, s.seed.Environment, cluster)
+}
+
+// CMK
+func (s *Server) getCluster(w http.ResponseWriter, r *http.Request) {
+	c := s.cluster(r.PathValue("id"))
+	if c == nil || r.URL.Query().Get("environment") != s.seed.Environment {
+		cloudError(w, http.StatusNotFound, "The requested cluster was not found.")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"api_version": "cmk/v2",
+		"kind":        "Cluster",
+		"id":          c.Id,
+		"metadata": map[string]interface{}{
+			"resource_name": s.crn(c.Id) + "/kafka=" + c.Id,
+		},
+		"spec": map[string]interface{}{
+			"display_name":             c.Name,
+			"kafka_bootstrap_endpoint": c.Bootstrap,
+			"http_endpoint":            "http://" + r.Host,
+			"environment":              map[string]interface{}{"id": s.seed.Environment},
+		},
+	})
+}
+
+func (s *Server) listClusters(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("environment") != s.seed.Environment {
+		cloudError(w, http.StatusNotFound, "The requested environment was not found.")
+		return
+	}
+	data := make([]interface{}, 0)
+	for _, c := range s.seed.Clusters {
+		data = append(data, map[string]interface{}{
+			"id":       c.Id,
+			"metadata": map[string]interface{}{"resource_name": s.crn(c.Id) + "/kafka=" + c.Id},
+			"spec":     map[string]interface{}{"display_name": c.Name},
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "metadata": map[string]interface{}{}})
+}
+
+// IAM
+func (s *Server) apiKey(k SeedApiKey) map[string]interface{} {
+	return map[string]interface{}{
+		"id": k.Id,
+		"spec": map[string]interface{}{
+			"owner":    map[string]interface{}{"id": k.Owner},
+			"resource": map[string]interface{}{"id": k.Resource, "environment": s.seed.Environment},
+		},
+	}
+}
+
+func (s *Server) listApiKeys(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("spec.resource")
+	data := make([]interface{}, 0)
+	for _, k := range s.seed.ApiKeys {
+		if resource == "" || k.Resource == resource {
+			data = append(data, s.apiKey(k))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) getApiKey(w http.ResponseWriter, r *http.Request) {
+	for _, k := range s.seed.ApiKeys {
+		if k.Id == r.PathValue("id") {
+			writeJSON(w, http.StatusOK, s.apiKey(k))
+			return
+		}
+	}
+	cloudError(w, http.StatusNotFound, "The requested api key was not found.")
+}
+
+func (s *Server) deleteApiKey(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i := slices.IndexFunc(s.seed.ApiKeys, func(k SeedApiKey) bool { return k.Id == id })
+	if i < 0 {
+		cloudError(w, http.StatusNotFound, "The requested api key was not found.")
+		return
+	}
+	s.seed.ApiKeys = slices.Delete(s.seed.ApiKeys, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	data := make([]interface{}, 0)
+	for _, sa := range s.seed.ServiceAccounts {
+		data = append(data, map[string]interface{}{"id": sa.Id, "display_name": sa.DisplayName})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	data := make([]interface{}, 0)
+	for _, u := range s.seed.Users {
+		data = append(data, map[string]interface{}{"id": u.Id, "email": u.Email, "full_name": u.FullName})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) listIdentityProviders(w http.ResponseWriter, r *http.Request) {
+	data := make([]interface{}, 0)
+	seen := make(map[string]bool)
+	for _, pool := range s.seed.IdentityPools {
+		if !seen[pool.Provider] {
+			seen[pool.Provider] = true
+			data = append(data, map[string]interface{}{"id": pool.Provider})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) listIdentityPools(w http.ResponseWriter, r *http.Request) {
+	data := make([]interface{}, 0)
+	for _, pool := range s.seed.IdentityPools {
+		if pool.Provider == r.PathValue("id") {
+			data = append(data, map[string]interface{}{"id": pool.Id, "display_name": pool.DisplayName})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) listRoleBindings(w http.ResponseWriter, r *http.Request) {
+	principal := r.URL.Query().Get("principal")
+	crnPattern := r.URL.Query().Get("crn_pattern")
+	data := make([]interface{}, 0)
+	for _, rb := range s.seed.RoleBindings {
+		if principal != "" && rb.Principal != principal {
+			continue
+		}
+		if crnPattern != "" && !strings.HasPrefix(rb.CrnPattern, strings.TrimSuffix(crnPattern, "/*")) {
+			continue
+		}
+		data = append(data, map[string]interface{}{
+			"id":          rb.Id,
+			"principal":   rb.Principal,
+			"role_name":   rb.RoleName,
+			"crn_pattern": rb.CrnPattern,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) deleteRoleBinding(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i := slices.IndexFunc(s.seed.RoleBindings, func(rb SeedRoleBinding) bool { return rb.Id == id })
+	if i < 0 {
+		cloudError(w, http.StatusNotFound, "The requested role binding was not found.")
+		return
+	}
+	s.seed.RoleBindings = slices.Delete(s.seed.RoleBindings, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// KAFKA
+func (s *Server) listTopics(w http.ResponseWriter, r *http.Request) {
+	c := s.cluster(r.PathValue("id"))
+	if c == nil {
+		kafkaError(w, http.StatusNotFound, "Cluster not found.")
+		return
+	}
+	data := make([]interface{}, 0)
+	for _, t := range c.Topics {
+		data = append(data, map[string]interface{}{
+			"cluster_id":         c.Id,
+			"topic_name":         t.Name,
+			"is_internal":        strings.HasPrefix(t.Name, "__"),
+			"replication_factor": t.ReplicationFactor,
+			"partitions_count":   t.Partitions,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"kind": "KafkaTopicList", "data": data})
+}
+
+func (s *Server) deleteTopic(w http.ResponseWriter, r *http.Request) {
+	c := s.cluster(r.PathValue("id"))
+	if c == nil {
+		kafkaError(w, http.StatusNotFound, "Cluster not found.")
+		return
+	}
+	name := r.PathValue("name")
+	i := slices.IndexFunc(c.Topics, func(t SeedTopic) bool { return t.Name == name })
+	if i < 0 {
+		kafkaError(w, http.StatusNotFound, "This server does not host this topic-partition.")
+		return
+	}
+	c.Topics = slices.Delete(c.Topics, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listTopicConfigs only returns the seeded configs, all of them as non default values
+func (s *Server) listTopicConfigs(w http.ResponseWriter, r *http.Request) {
+	c := s.cluster(r.PathValue("id"))
+	if c == nil {
+		kafkaError(w, http.StatusNotFound, "Cluster not found.")
+		return
+	}
+	name := r.PathValue("name")
+	i := slices.IndexFunc(c.Topics, func(t SeedTopic) bool { return t.Name == name })
+	if i < 0 {
+		kafkaError(w, http.StatusNotFound, "This server does not host this topic-partition.")
+		return
+	}
+	data := make([]interface{}, 0)
+	for key, value := range c.Topics[i].Configs {
+		data = append(data, map[string]interface{}{
+			"cluster_id": c.Id,
+			"topic_name": name,
+			"name":       key,
+			"value":      value,
+			"is_default": false,
+			"source":     "DYNAMIC_TOPIC_CONFIG",
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"kind": "KafkaTopicConfigList", "data": data})
+}
+
+func (s *Server) listAcls(w http.ResponseWriter, r *http.Request) {
+	c := s.cluster(r.PathValue("id"))
+	if c == nil {
+		kafkaError(w, http.StatusNotFound, "Cluster not found.")
+		return
+	}
+	data := make([]interface{}, 0)
+	for _, a := range c.Acls {
+		data = append(data, map[string]interface{}{
+			"cluster_id":    c.Id,
+			"resource_type": a.ResourceType,
+			"resource_name": a.ResourceName,
+			"pattern_type":  a.PatternType,
+			"principal":     a.Principal,
+			"host":          a.Host,
+			"operation":     a.Operation,
+			"permission":    a.Permission,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"kind": "KafkaAclList", "data": data})
+}
+
+func (s *Server) createAcl(w http.ResponseWriter, r *http.Request) {
+	c := s.cluster(r.PathValue("id"))
+	if c == nil {
+		kafkaError(w, http.StatusNotFound, "Cluster not found.")
+		return
+	}
+	var a SeedAcl
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		kafkaError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Acls = append(c.Acls, a)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// deleteAcls removes the ACLs matching every query parameter provided
+func (s *Server) deleteAcls(w http.ResponseWriter, r *http.Request) {
+	c := s.cluster(r.PathValue("id"))
+	if c == nil {
+		kafkaError(w, http.StatusNotFound, "Cluster not found.")
+		return
+	}
+	q := r.URL.Query()
+	match := func(filter, value string) bool {
+		return filter == "" || filter == "ANY" || filter == value
+	}
+	deleted := make([]interface{}, 0)
+	kept := make([]SeedAcl, 0, len(c.Acls))
+	for _, a := range c.Acls {
+		if match(q.Get("resource_type"), a.ResourceType) && match(q.Get("resource_name"), a.ResourceName) &&
+			match(q.Get("pattern_type"), a.PatternType) && match(q.Get("principal"), a.Principal) &&
+			match(q.Get("host"), a.Host) && match(q.Get("operation"), a.Operation) && match(q.Get("permission"), a.Permission) {
+			deleted = append(deleted, a)
+			continue
+		}
+		kept = append(kept, a)
+	}
+	c.Acls = kept
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": deleted})
+}
+
+// CONNECT
+func (s *Server) listConnectors(w http.ResponseWriter, r *http.Request) {
+	c := s.cluster(r.PathValue("id"))
+	if c == nil || r.PathValue("env") != s.seed.Environment {
+		cloudError(w, http.StatusNotFound, "The requested cluster was not found.")
+		return
+	}
+	data := make(map[string]interface{})
+	for _, con := range c.Connectors {
+		data[con.Name] = map[string]interface{}{
+			"id":   map[string]interface{}{"id": con.Id, "id_type": "ID"},
+			"info": map[string]interface{}{"name": con.Name, "type": con.Type, "config": map[string]string{}},
+			"status": map[string]interface{}{
+				"name":      con.Name,
+				"type":      con.Type,
+				"connector": map[string]interface{}{"state": con.State},
+			},
+		}
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+func (s *Server) deleteConnector(w http.ResponseWriter, r *http.Request) {
+	c := s.cluster(r.PathValue("id"))
+	if c == nil || r.PathValue("env") != s.seed.Environment {
+		cloudError(w, http.StatusNotFound, "The requested cluster was not found.")
+		return
+	}
+	name := r.PathValue("name")
+	i := slices.IndexFunc(c.Connectors, func(con SeedConnector) bool { return con.Name == name })
+	if i < 0 {
+		cloudError(w, http.StatusNotFound, "The requested connector was not found.")
+		return
+	}
+	c.Connectors = slices.Delete(c.Connectors, i, i+1)
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+// SCHEMA REGISTRY
+func (s *Server) listSchemaRegistryClusters(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("environment") != s.seed.Environment {
+		cloudError(w, http.StatusNotFound, "The requested environment was not found.")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": []interface{}{
+			map[string]interface{}{
+				"id":   s.seed.SchemaRegistry.Id,
+				"spec": map[string]interface{}{"http_endpoint": "http://" + r.Host},
+			},
+		},
+	})
+}
+
+func (s *Server) listSubjects(w http.ResponseWriter, r *http.Request) {
+	subjects := s.seed.SchemaRegistry.Subjects
+	if subjects == nil {
+		subjects = []string{}
+	}
+	writeJSON(w, http.StatusOK, subjects)
+}
+
+// deleteSubject removes the subject, the fake keeps a single version per subject
+func (s *Server) deleteSubject(w http.ResponseWriter, r *http.Request) {
+	subject := r.PathValue("subject")
+	i := slices.Index(s.seed.SchemaRegistry.Subjects, subject)
+	if i < 0 {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"error_code": 40401, "message": "Subject '" + subject + "' not found."})
+		return
+	}
+	s.seed.SchemaRegistry.Subjects = slices.Delete(s.seed.SchemaRegistry.Subjects, i, i+1)
+	writeJSON(w, http.StatusOK, []int{1})
+}
+
+// listSchemas answers the latest schemas, version 1 of every subject
+func (s *Server) listSchemas(w http.ResponseWriter, r *http.Request) {
+	data := make([]interface{}, 0)
+	for i, subject := range s.seed.SchemaRegistry.Subjects {
+		data = append(data, map[string]interface{}{"subject": subject, "version": 1, "id": 100001 + i, "schema": `"string"`})
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+func (s *Server) catalogEntity(r *http.Request) *SeedCatalogEntity {
+	for i, e := range s.seed.Catalog {
+		if e.Type == r.PathValue("type") && e.QualifiedName == r.PathValue("name") {
+			return &s.seed.Catalog[i]
+		}
+	}
+	return nil
+}
+
+func catalogEntries(e *SeedCatalogEntity, entries []SeedCatalogEntry) []interface{} {
+	data := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, map[string]interface{}{
+			"typeName":   entry.Name,
+			"entityType": e.Type,
+			"entityName": e.QualifiedName,
+			"attributes": entry.Attributes,
+		})
+	}
+	return data
+}
+
+func (s *Server) listCatalogTags(w http.ResponseWriter, r *http.Request) {
+	e := s.catalogEntity(r)
+	if e == nil {
+		kafkaError(w, http.StatusNotFound, "Instance "+r.PathValue("type")+" with unique attribute qualifiedName="+r.PathValue("name")+" does not exist")
+		return
+	}
+	writeJSON(w, http.StatusOK, catalogEntries(e, e.Tags))
+}
+
+func (s *Server) listCatalogBusinessMetadata(w http.ResponseWriter, r *http.Request) {
+	e := s.catalogEntity(r)
+	if e == nil {
+		kafkaError(w, http.StatusNotFound, "Instance "+r.PathValue("type")+" with unique attribute qualifiedName="+r.PathValue("name")+" does not exist")
+		return
+	}
+	writeJSON(w, http.StatusOK, catalogEntries(e, e.BusinessMetadata))
+}
+
+// TELEMETRY
+type metricsQuery struct {
+	Aggregations []struct {
+		Metric string `json:"metric"`
+	} `json:"aggregations"`
+	Filter  metricsFilter `json:"filter"`
+	GroupBy []string      `json:"group_by"`
+	Limit   int           `json:"limit"`
+}
+
+type metricsFilter struct {
+	Field   string          `json:"field"`
+	Op      string          `json:"op"`
+	Value   string          `json:"value"`
+	Filters []metricsFilter `json:"filters"`
+}
+
+// matches checks the eq filters of a field, and every filter of an AND
+func (f metricsFilter) matches(field, value string) bool {
+	if f.Op == "AND" {
+		for _, filter := range f.Filters {
+			if !filter.matches(field, value) {
+				return false
+			}
+		}
+		return true
+	}
+	return f.Field != field || f.Value == value
+}
+
+// queryMetrics answers with one data point per seeded value of the requested metric,
+// labelled with the first group_by field of the query and sorted by label.
+// The data points are filtered on the label and paginated with the limit of the query, page_token is an offset.
+func (s *Server) queryMetrics(w http.ResponseWriter, r *http.Request) {
+	var query metricsQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil || len(query.Aggregations) == 0 {
+		cloudError(w, http.StatusBadRequest, "Invalid metrics query.")
+		return
+	}
+	group := ""
+	if len(query.GroupBy) > 0 {
+		group = query.GroupBy[0]
+	}
+	values := s.seed.Metrics[query.Aggregations[0].Metric]
+	labels := make([]string, 0, len(values))
+	for label := range values {
+		if query.Filter.matches(group, label) {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	offset, _ := strconv.Atoi(r.URL.Query().Get("page_token"))
+	labels = labels[min(offset, len(labels)):]
+	meta := map[string]interface{}{}
+	if query.Limit > 0 && len(labels) > query.Limit {
+		labels = labels[:query.Limit]
+		meta["pagination"] = map[string]interface{}{"next_page_token": strconv.Itoa(offset + query.Limit)}
+	}
+	data := make([]interface{}, 0)
+	timestamp := time.Now().UTC().Truncate(24 * time.Hour).Format(time.RFC3339)
+	for _, label := range labels {
+		point := map[string]interface{}{"timestamp": timestamp, "value": values[label]}
+		if group != "" {
+			point[group] = label
+		}
+		data = append(data, point)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "meta": meta})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func cloudError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors": []interface{}{
+			map[string]interface{}{"status": fmt.Sprint(status), "detail": detail},
+		},
+	})
+}
+
+func kafkaError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"error_code": status, "message": message})
+}
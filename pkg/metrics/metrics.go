@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/confluent"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+const namespace = "cleanup"
+
+// Metrics are the housekeeping KPIs, computed from the reports and results of the cleaners.
+// It is an observer of the cleaners, gauges are set on every scan and counters on every apply.
+type Metrics struct {
+	Registry                *prometheus.Registry
+	inactiveTopics          *prometheus.GaugeVec
+	orphanAcls              *prometheus.GaugeVec
+	inactiveServiceAccounts *prometheus.GaugeVec
+	failedConnectors        *prometheus.GaugeVec
+	reclaimableBytes        *prometheus.GaugeVec
+	reclaimablePartitions   *prometheus.GaugeVec
+	monthlySaving           *prometheus.GaugeVec
+	candidates              *prometheus.GaugeVec
+	scanDuration            *prometheus.GaugeVec
+	lastScan                *prometheus.GaugeVec
+	deleted                 *prometheus.CounterVec
+	deleteFailures          *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		inactiveTopics: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "inactive_topics",
+			Help: "Topics without received records in the last 7 days, not yet quarantined or shrunk.",
+		}, []string{"cluster"}),
+		orphanAcls: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "orphan_acls",
+			Help: "ACLs of topics, consumer groups, their prefixes or principals that don't exist, by status.",
+		}, []string{"cluster", "status"}),
+		inactiveServiceAccounts: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "inactive_service_accounts",
+			Help: "Service accounts with cluster API KEYs and without connections in the last 7 days.",
+		}, []string{"cluster"}),
+		failedConnectors: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "failed_connectors",
+			Help: "Connectors in FAILED state.",
+		}, []string{"cluster"}),
+		reclaimableBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "reclaimable_bytes",
+			Help: "Retained bytes of the topics that are deletion candidates.",
+		}, []string{"cluster"}),
+		reclaimablePartitions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "reclaimable_partitions",
+			Help: "Partitions of the topics that are deletion candidates.",
+		}, []string{"cluster"}),
+		monthlySaving: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "estimated_monthly_saving_dollars",
+			Help: "Estimated monthly cost of the topics that are deletion candidates.",
+		}, []string{"cluster"}),
+		candidates: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "candidates",
+			Help: "Deletion candidates of the last scan.",
+		}, []string{"cluster", "cleaner"}),
+		scanDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "scan_duration_seconds",
+			Help: "Duration of the last scan.",
+		}, []string{"cluster", "cleaner"}),
+		lastScan: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "last_scan_timestamp_seconds",
+			Help: "Time of the last scan.",
+		}, []string{"cluster", "cleaner"}),
+		deleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "deleted_total",
+			Help: "Resources deleted, quarantined or restored.",
+		}, []string{"cluster", "kind", "action"}),
+		deleteFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "delete_failures_total",
+			Help: "Resources that could not be deleted, quarantined or restored.",
+		}, []string{"cluster", "kind", "action"}),
+	}
+	m.Registry.MustRegister(m.inactiveTopics, m.orphanAcls, m.inactiveServiceAccounts, m.failedConnectors,
+		m.reclaimableBytes, m.reclaimablePartitions, m.monthlySaving, m.candidates, m.scanDuration, m.lastScan, m.deleted, m.deleteFailures)
+	return m
+}
+
+func (m *Metrics) Scanned(report cleaner.Report) error {
+	cluster := prometheus.Labels{"cluster": report.Cluster}
+	labels := prometheus.Labels{"cluster": report.Cluster, "cleaner": report.Cleaner}
+	m.candidates.With(labels).Set(float64(len(report.Candidates())))
+	m.scanDuration.With(labels).Set(report.Duration.Seconds())
+	m.lastScan.With(labels).SetToCurrentTime()
+
+	switch report.Cleaner {
+	case "topics":
+		m.inactiveTopics.With(cluster).Set(float64(countStatus(report, confluent.InactiveStatus)))
+		reclaimable := confluent.Reclaimable(report)
+		m.reclaimableBytes.With(cluster).Set(reclaimable.RetainedBytes)
+		m.reclaimablePartitions.With(cluster).Set(float64(reclaimable.Partitions))
+		saving := 0.0
+		for _, f := range report.Candidates() {
+			cost, _ := strconv.ParseFloat(f.Attributes[confluent.AttrMonthlyCost], 64)
+			saving += cost
+		}
+		m.monthlySaving.With(cluster).Set(saving)
+	case "acls":
+		m.orphanAcls.DeletePartialMatch(cluster)
+		for _, f := range report.Findings {
+			if f.Status != confluent.ActiveStatus {
+				m.orphanAcls.WithLabelValues(report.Cluster, f.Status).Inc()
+			}
+		}
+	case "service-accounts":
+		m.inactiveServiceAccounts.With(cluster).Set(float64(countStatus(report, confluent.InactiveStatus)))
+	case "connectors":
+		m.failedConnectors.With(cluster).Set(float64(countStatus(report, string(confluent.FAILED))))
+	}
+	return nil
+}
+
+func (m *Metrics) Planned(plan cleaner.Plan) error {
+	return nil
+}
+
+func (m *Metrics) Applied(result cleaner.Result) error {
+	for _, item := range result.Items {
+		counter := m.deleted
+		if !item.Succeeded() {
+			counter = m.deleteFailures
+		}
+		counter.WithLabelValues(result.Cluster, string(item.Kind), item.Action).Inc()
+	}
+	return nil
+}
+
+// Push replaces the metrics of the <cluster>:<cleaner> instance in a Pushgateway, at the end of a one-shot run.
+// The cluster and cleaner are metric labels, they can't be grouping labels too.
+func (m *Metrics) Push(url, cleanerName, cluster string) error {
+	return push.New(url, namespace).
+		Gatherer(m.Registry).
+		Grouping("instance", cluster+":"+cleanerName).
+		Push()
+}
+
+func countStatus(report cleaner.Report, status string) int {
+	count := 0
+	for _, f := range report.Findings {
+		if f.Status == status {
+			count++
+		}
+	}
+	return count
+}
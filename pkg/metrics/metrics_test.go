@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/confluent"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInactiveTopics(t *testing.T) {
+	m := New()
+	report := cleaner.Report{Cleaner: "topics", Cluster: "lkc-1", Findings: []cleaner.Finding{
+		{Id: "orders", Status: confluent.ActiveStatus},
+		{Id: "legacy", Status: confluent.InactiveStatus, Candidate: true},
+		{Id: "quarantined", Status: confluent.QuarantinedStatus},
+		{Id: "shrunk", Status: confluent.ShrunkStatus},
+		{Id: "retired", Status: confluent.ProtectedStatus},
+	}}
+	if err := m.Scanned(report); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(m.inactiveTopics.WithLabelValues("lkc-1")); got != 1 {
+		t.Errorf("inactive_topics = %v, want 1", got)
+	}
+}
@@ -0,0 +1,256 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Phases of a cleanup run
+const (
+	PhaseScan  = "scan"
+	PhasePlan  = "plan"
+	PhaseApply = "apply"
+	PhaseError = "error"
+)
+
+// Filters
+const (
+	FilterAll       = "all"
+	FilterDeletions = "deletions"
+	FilterErrors    = "errors"
+)
+
+// Config of the notifiers, loaded from YAML
+type Config struct {
+	Notifiers []NotifierConfig `yaml:"notifiers"`
+}
+
+type NotifierConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Preset payload: slack, teams or json
+	Preset string `yaml:"preset"`
+	// Template is a Go template of the payload, it overrides the preset
+	Template string            `yaml:"template"`
+	Headers  map[string]string `yaml:"headers"`
+	// Phases to notify: scan, plan, apply, error. Default all
+	Phases []string `yaml:"phases"`
+	// Filter: all, deletions (runs deleting resources) or errors (failed runs or deletions)
+	Filter  string        `yaml:"filter"`
+	Retries int           `yaml:"retries"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// LoadConfig reads the notifiers configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid notifiers file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Summary of a cleanup phase, it is the data of the payload templates
+type Summary struct {
+	Phase       string    `json:"phase"`
+	Cleaner     string    `json:"cleaner"`
+	Environment string    `json:"environment,omitempty"`
+	Cluster     string    `json:"cluster,omitempty"`
+	Operator    string    `json:"operator"`
+	Time        time.Time `json:"time"`
+	Findings    int       `json:"findings"`
+	Candidates  []string  `json:"candidates,omitempty"`
+	// Outcome of the applied items: DELETED, QUARANTINED, RESTORED, RECREATED, ALTERED or SHRUNK
+	Outcome string    `json:"outcome,omitempty"`
+	Deleted []string  `json:"deleted,omitempty"`
+	Failed  []Failure `json:"failed,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+type Failure struct {
+	Kind  cleaner.Kind `json:"kind"`
+	Id    string       `json:"id"`
+	Error string       `json:"error"`
+}
+
+// Notifier posts run summaries to a webhook
+type Notifier struct {
+	config   NotifierConfig
+	template *template.Template
+	client   *http.Client
+	Operator string
+}
+
+func NewNotifier(config NotifierConfig, operator string) (*Notifier, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("notifier %s: url is required", config.Name)
+	}
+	text := config.Template
+	if text == "" {
+		preset, ok := presets[config.Preset]
+		if !ok {
+			return nil, fmt.Errorf("notifier %s: unknown preset %q", config.Name, config.Preset)
+		}
+		text = preset
+	}
+	tmpl, err := newTemplate(config.Name, text)
+	if err != nil {
+		return nil, fmt.Errorf("notifier %s: invalid template: %w", config.Name, err)
+	}
+	if config.Filter == "" {
+		config.Filter = FilterAll
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &Notifier{
+		config:   config,
+		template: tmpl,
+		client:   &http.Client{Timeout: config.Timeout},
+		Operator: operator,
+	}, nil
+}
+
+// NewNotifiers builds every notifier of a configuration
+func NewNotifiers(config *Config, operator string) ([]*Notifier, error) {
+	notifiers := make([]*Notifier, 0, len(config.Notifiers))
+	for _, c := range config.Notifiers {
+		n, err := NewNotifier(c, operator)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+func (n *Notifier) Scanned(report cleaner.Report) error {
+	summary := n.summary(PhaseScan, report.Cleaner, report.Environment, report.Cluster)
+	summary.Findings = len(report.Findings)
+	for _, f := range report.Candidates() {
+		summary.Candidates = append(summary.Candidates, f.Id)
+	}
+	return n.Notify(summary)
+}
+
+func (n *Notifier) Planned(plan cleaner.Plan) error {
+	summary := n.summary(PhasePlan, plan.Cleaner, plan.Environment, plan.Cluster)
+	for _, f := range plan.Findings {
+		summary.Candidates = append(summary.Candidates, f.Id)
+	}
+	return n.Notify(summary)
+}
+
+func (n *Notifier) Applied(result cleaner.Result) error {
+	summary := n.summary(PhaseApply, result.Cleaner, result.Environment, result.Cluster)
+	summary.Outcome = cleaner.ItemResult{Action: cleaner.ActionDelete}.Outcome()
+	for _, item := range result.Items {
+		if item.Succeeded() {
+			summary.Outcome = item.Outcome()
+			summary.Deleted = append(summary.Deleted, item.Id)
+		} else {
+			summary.Failed = append(summary.Failed, Failure{Kind: item.Kind, Id: item.Id, Error: item.Err.Error()})
+		}
+	}
+	return n.Notify(summary)
+}
+
+func (n *Notifier) Failed(cleanerName string, err error) error {
+	summary := n.summary(PhaseError, cleanerName, "", "")
+	summary.Error = err.Error()
+	return n.Notify(summary)
+}
+
+func (n *Notifier) summary(phase, cleanerName, environment, cluster string) Summary {
+	return Summary{
+		Phase:       phase,
+		Cleaner:     cleanerName,
+		Environment: environment,
+		Cluster:     cluster,
+		Operator:    n.Operator,
+		Time:        time.Now().UTC(),
+	}
+}
+
+// accepts checks the phase and the filter of the notifier
+func (n *Notifier) accepts(s Summary) bool {
+	if len(n.config.Phases) > 0 {
+		found := false
+		for _, p := range n.config.Phases {
+			found = found || p == s.Phase
+		}
+		if !found {
+			return false
+		}
+	}
+	switch n.config.Filter {
+	case FilterDeletions:
+		return len(s.Deleted) > 0
+	case FilterErrors:
+		return s.Error != "" || len(s.Failed) > 0
+	}
+	return true
+}
+
+// Notify renders the payload and posts it, retrying on network errors, 429 and 5xx responses
+func (n *Notifier) Notify(s Summary) error {
+	if !n.accepts(s) {
+		return nil
+	}
+	var payload bytes.Buffer
+	if err := n.template.Execute(&payload, s); err != nil {
+		return fmt.Errorf("notifier %s: rendering payload: %w", n.config.Name, err)
+	}
+	var err error
+	backoff := time.Second
+	for attempt := 0; attempt <= n.config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		var retry bool
+		retry, err = n.post(payload.Bytes())
+		if err == nil || !retry {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("notifier %s: %w", n.config.Name, err)
+	}
+	return nil
+}
+
+func (n *Notifier) post(payload []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, n.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.config.Headers {
+		req.Header.Set(k, v)
+	}
+	res, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+	err = errors.New(res.Status)
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500, err
+}
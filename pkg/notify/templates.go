@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+)
+
+var funcs = template.FuncMap{
+	// json encodes a value, strings are quoted and escaped
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// newTemplate parses a payload template, named templates can be rendered with include
+func newTemplate(name, text string) (*template.Template, error) {
+	tmpl := template.New(name)
+	tmpl.Funcs(funcs).Funcs(template.FuncMap{
+		"include": func(name string, data interface{}) (string, error) {
+			var b bytes.Buffer
+			err := tmpl.ExecuteTemplate(&b, name, data)
+			return b.String(), err
+		},
+	})
+	return tmpl.Parse(text)
+}
+
+// The text shared by the Slack and Teams presets
+const summaryText = `{{define "title"}}cleanup {{.Cleaner}} {{.Phase}} on {{.Cluster}}{{end}}` +
+	`{{define "text"}}{{if .Error}}Error: {{.Error}}` +
+	`{{else if eq .Phase "scan"}}{{.Findings}} resources scanned, {{len .Candidates}} deletion candidates{{if .Candidates}}: {{join .Candidates ", "}}{{end}}` +
+	`{{else if eq .Phase "plan"}}{{len .Candidates}} resources planned for deletion{{if .Candidates}}: {{join .Candidates ", "}}{{end}}` +
+	`{{else}}{{len .Deleted}} {{lower .Outcome}}, {{len .Failed}} failed{{range .Failed}}` + "\n" + `- {{.Kind}} {{.Id}}: {{.Error}}{{end}}{{end}}` +
+	` (operator {{.Operator}}){{end}}`
+
+var presets = map[string]string{
+	// Slack incoming webhook
+	"slack": summaryText + `{"text": {{json (printf "*%s*\n%s" (include "title" .) (include "text" .))}}}`,
+	// Microsoft Teams incoming webhook, legacy MessageCard
+	"teams": summaryText + `{"@type": "MessageCard", "@context": "https://schema.org/extensions", ` +
+		`"summary": {{json (include "title" .)}}, "title": {{json (include "title" .)}}, "text": {{json (include "text" .)}}}`,
+	// The summary as JSON
+	"json": `{{json .}}`,
+	"":     `{{json .}}`,
+}
@@ -0,0 +1,51 @@
+package outputs
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "check.xml")
+	report := JUnitTestSuites{Name: "cleanup check", Suites: []JUnitTestSuite{
+		{Name: "topics", Cases: []JUnitTestCase{
+			{Name: "orders", Classname: "topics"},
+			{Name: "legacy", Classname: "topics", Failure: &JUnitMessage{Message: "INACTIVE"}},
+			{Name: "retired", Classname: "topics", Skipped: &JUnitMessage{Message: "PROTECTED"}},
+		}},
+		{Name: "acls", Cases: []JUnitTestCase{
+			{Name: "scan", Classname: "acls", Error: &JUnitMessage{Message: "forbidden"}},
+		}},
+		{Name: "connectors"},
+	}}
+	if err := WriteJUnit(path, report); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got JUnitTestSuites
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshalling the report: %v", err)
+	}
+	if got.Tests != 4 || got.Failures != 1 || got.Errors != 1 {
+		t.Errorf("report counts = %d tests, %d failures, %d errors, want 4, 1, 1", got.Tests, got.Failures, got.Errors)
+	}
+	want := []struct{ tests, failures, errors, skipped int }{{3, 1, 0, 1}, {1, 0, 1, 0}, {0, 0, 0, 0}}
+	if len(got.Suites) != len(want) {
+		t.Fatalf("report = %d suites, want %d", len(got.Suites), len(want))
+	}
+	for i, w := range want {
+		s := got.Suites[i]
+		if s.Tests != w.tests || s.Failures != w.failures || s.Errors != w.errors || s.Skipped != w.skipped {
+			t.Errorf("suite %s counts = %d/%d/%d/%d, want %d/%d/%d/%d", s.Name,
+				s.Tests, s.Failures, s.Errors, s.Skipped, w.tests, w.failures, w.errors, w.skipped)
+		}
+	}
+	if got.Suites[0].Cases[1].Failure == nil || got.Suites[0].Cases[1].Failure.Message != "INACTIVE" {
+		t.Errorf("failure of legacy = %+v, want INACTIVE", got.Suites[0].Cases[1].Failure)
+	}
+}
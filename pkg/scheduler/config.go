@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/bulk"
+	"mcolomer/cloud-keeping/pkg/confluent"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Actions of a job on inactive topics
+const (
+	ActionDelete     = "delete"
+	ActionQuarantine = "quarantine"
+	ActionShrink     = "shrink"
+)
+
+// Cleaners run by a job when none is configured
+var DefaultCleaners = []string{"topics", "acls", "service-accounts", "connectors"}
+
+// Config of the scheduler daemon, loaded from YAML.
+// ${VAR} references are expanded from the environment, to keep the secrets out of the file.
+type Config struct {
+	CloudApiKey    string          `yaml:"cloud_api_key"`
+	CloudApiSecret string          `yaml:"cloud_api_secret"`
+	Endpoints      EndpointsConfig `yaml:"endpoints"`
+	Prices         PricesConfig    `yaml:"prices"`
+	Bulk           BulkConfig      `yaml:"bulk"`
+	// Runs kept per job, default 20
+	History int         `yaml:"history"`
+	Jobs    []JobConfig `yaml:"jobs"`
+}
+
+type EndpointsConfig struct {
+	Cloud          string `yaml:"cloud"`
+	Metrics        string `yaml:"metrics"`
+	KafkaRest      string `yaml:"kafka_rest"`
+	KafkaBootstrap string `yaml:"kafka_bootstrap"`
+	SchemaRegistry string `yaml:"schema_registry"`
+}
+
+func (e EndpointsConfig) endpoints() confluent.Endpoints {
+	return confluent.Endpoints{
+		Cloud:          e.Cloud,
+		Metrics:        e.Metrics,
+		KafkaRest:      e.KafkaRest,
+		KafkaBootstrap: e.KafkaBootstrap,
+		SchemaRegistry: e.SchemaRegistry,
+	}
+}
+
+// PricesConfig of the cost estimates, the storage price defaults to the list price
+type PricesConfig struct {
+	GBMonth        *float64 `yaml:"gb_month"`
+	PartitionMonth float64  `yaml:"partition_month"`
+}
+
+func (p PricesConfig) prices() confluent.Prices {
+	prices := confluent.Prices{GBMonth: confluent.DefaultPrices.GBMonth, PartitionMonth: p.PartitionMonth}
+	if p.GBMonth != nil {
+		prices.GBMonth = *p.GBMonth
+	}
+	return prices
+}
+
+// BulkConfig of the bulk deletions, unset options use the defaults
+type BulkConfig struct {
+	ChunkSize   int           `yaml:"chunk_size"`
+	Concurrency int           `yaml:"concurrency"`
+	Timeout     time.Duration `yaml:"timeout"`
+}
+
+func (b BulkConfig) options() bulk.Options {
+	return bulk.Options{ChunkSize: b.ChunkSize, Concurrency: b.Concurrency, Timeout: b.Timeout}
+}
+
+// JobConfig is a cleanup job run on a cron schedule
+type JobConfig struct {
+	Name string `yaml:"name"`
+	// Standard cron expression, e.g. "0 3 * * *", or a descriptor like @daily or @every 6h
+	Schedule    string          `yaml:"schedule"`
+	Environment string          `yaml:"environment"`
+	Clusters    []ClusterConfig `yaml:"clusters"`
+	// Cleaners: topics, acls, service-accounts, connectors. Default all
+	Cleaners []string `yaml:"cleaners"`
+	// Apply deletes (quarantines or shrinks) the candidates, otherwise the job only plans
+	Apply bool `yaml:"apply"`
+	// Policies
+	GracePeriod     time.Duration `yaml:"grace_period"`
+	Action          string        `yaml:"action"`
+	AdminPrincipals []string      `yaml:"admin_principals"`
+}
+
+type ClusterConfig struct {
+	Id                      string `yaml:"id"`
+	ApiKey                  string `yaml:"api_key"`
+	ApiSecret               string `yaml:"api_secret"`
+	SchemaRegistryApiKey    string `yaml:"schema_registry_api_key"`
+	SchemaRegistryApiSecret string `yaml:"schema_registry_api_secret"`
+}
+
+// LoadConfig reads and validates the scheduler configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &config); err != nil {
+		return nil, fmt.Errorf("invalid scheduler file %s: %w", path, err)
+	}
+	if config.History == 0 {
+		config.History = 20
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scheduler file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+func (c *Config) Validate() error {
+	if c.CloudApiKey == "" || c.CloudApiSecret == "" {
+		return fmt.Errorf("cloud_api_key and cloud_api_secret are required")
+	}
+	names := make([]string, 0, len(c.Jobs))
+	for i := range c.Jobs {
+		job := &c.Jobs[i]
+		if job.Name == "" {
+			return fmt.Errorf("job %d: name is required", i)
+		}
+		if slices.Contains(names, job.Name) {
+			return fmt.Errorf("job %s: duplicated name", job.Name)
+		}
+		names = append(names, job.Name)
+		if _, err := cron.ParseStandard(job.Schedule); err != nil {
+			return fmt.Errorf("job %s: invalid schedule %q: %w", job.Name, job.Schedule, err)
+		}
+		if job.Environment == "" {
+			return fmt.Errorf("job %s: environment is required", job.Name)
+		}
+		if len(job.Clusters) == 0 {
+			return fmt.Errorf("job %s: at least a cluster is required", job.Name)
+		}
+		for _, cluster := range job.Clusters {
+			if cluster.Id == "" || cluster.ApiKey == "" || cluster.ApiSecret == "" {
+				return fmt.Errorf("job %s: cluster id, api_key and api_secret are required", job.Name)
+			}
+		}
+		if len(job.Cleaners) == 0 {
+			job.Cleaners = DefaultCleaners
+		}
+		for _, name := range job.Cleaners {
+			if !slices.Contains(confluent.CleanerNames, name) {
+				return fmt.Errorf("job %s: unknown cleaner %s", job.Name, name)
+			}
+		}
+		if job.Action == "" {
+			job.Action = ActionDelete
+		}
+		if job.Action != ActionDelete && job.Action != ActionQuarantine && job.Action != ActionShrink {
+			return fmt.Errorf("job %s: invalid action %s, use delete, quarantine or shrink", job.Name, job.Action)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigCleaners(t *testing.T) {
+	tests := []struct {
+		cleaners string
+		wantErr  bool
+	}{
+		{"[topics, acls]", false},
+		{"[api-keys, role-bindings, schemas]", false},
+		{"[unknown]", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cleaners, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scheduler.yaml")
+			config := `cloud_api_key: cloud-key
+cloud_api_secret: cloud-secret
+jobs:
+  - name: nightly
+    schedule: "0 2 * * *"
+    environment: env-1
+    clusters:
+      - id: lkc-1
+        api_key: key
+        api_secret: secret
+    cleaners: ` + tt.cleaners + "\n"
+			if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadConfig(path); (err != nil) != tt.wantErr {
+				t.Errorf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
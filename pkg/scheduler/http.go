@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// JobStatus is a scheduled job and its last run
+type JobStatus struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	Apply    bool      `json:"apply"`
+	Next     time.Time `json:"next"`
+	LastRun  *Run      `json:"last_run,omitempty"`
+	Clusters []string  `json:"clusters"`
+	Cleaners []string  `json:"cleaners"`
+}
+
+// Handler serves /healthz, /jobs and /jobs/{name}/runs
+func (s *Scheduler) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /jobs", s.jobs)
+	mux.HandleFunc("GET /jobs/{name}/runs", s.runs)
+	return mux
+}
+
+func (s *Scheduler) healthz(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	health := map[string]interface{}{
+		"status":    "ok",
+		"config":    s.Path,
+		"loaded_at": s.loadedAt,
+		"jobs":      len(s.config.Jobs),
+	}
+	// The daemon keeps running the previous jobs with an invalid configuration, but it is unhealthy
+	if s.configErr != nil {
+		health["status"] = "error"
+		health["config_error"] = s.configErr.Error()
+		writeJSON(w, http.StatusServiceUnavailable, health)
+		return
+	}
+	writeJSON(w, http.StatusOK, health)
+}
+
+func (s *Scheduler) jobs(w http.ResponseWriter, r *http.Request) {
+	history, err := s.History.Load()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]JobStatus, 0, len(s.config.Jobs))
+	for _, job := range s.config.Jobs {
+		status := JobStatus{
+			Name:     job.Name,
+			Schedule: job.Schedule,
+			Apply:    job.Apply,
+			Next:     s.cron.Entry(s.entries[job.Name]).Next,
+			Cleaners: job.Cleaners,
+		}
+		for _, c := range job.Clusters {
+			status.Clusters = append(status.Clusters, c.Id)
+		}
+		if runs := history[job.Name]; len(runs) > 0 {
+			status.LastRun = &runs[len(runs)-1]
+		}
+		jobs = append(jobs, status)
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Scheduler) runs(w http.ResponseWriter, r *http.Request) {
+	history, err := s.History.Load()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	runs, ok := history[r.PathValue("name")]
+	if !ok {
+		runs = make([]Run, 0)
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
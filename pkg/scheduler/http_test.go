@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthzConfigError(t *testing.T) {
+	s := &Scheduler{config: &Config{}}
+	tests := []struct {
+		name      string
+		configErr error
+		want      int
+	}{
+		{"valid", nil, http.StatusOK},
+		{"invalid", errors.New("job nightly: invalid schedule"), http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.configErr = tt.configErr
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if w.Code != tt.want {
+				t.Errorf("GET /healthz = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
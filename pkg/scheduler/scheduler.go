@@ -0,0 +1,307 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/confluent"
+	"mcolomer/cloud-keeping/pkg/state"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/robfig/cron/v3"
+)
+
+// Run statuses
+const (
+	RunSucceeded = "SUCCEEDED"
+	RunFailed    = "FAILED"
+)
+
+// Run is an execution of a job
+type Run struct {
+	Id         string      `json:"id"`
+	Job        string      `json:"job"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt time.Time   `json:"finished_at"`
+	Apply      bool        `json:"apply"`
+	Status     string      `json:"status"`
+	Results    []RunResult `json:"results"`
+}
+
+// RunResult is the outcome of a cleaner on a cluster
+type RunResult struct {
+	Cluster    string `json:"cluster"`
+	Cleaner    string `json:"cleaner"`
+	Findings   int    `json:"findings"`
+	Candidates int    `json:"candidates"`
+	Applied    int    `json:"applied"`
+	Failed     int    `json:"failed"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ObserversFunc builds the observers of a run on a cluster
+type ObserversFunc func(clean *confluent.ConfluentClean, cluster ClusterConfig, operator, runId string) ([]cleaner.Observer, error)
+
+// Scheduler runs the cleanup jobs of a configuration file on their cron schedules.
+// The configuration is reloaded when the file changes, an invalid file keeps the previous jobs.
+type Scheduler struct {
+	mu        sync.Mutex
+	Path      string
+	config    *Config
+	configErr error
+	loadedAt  time.Time
+	cron      *cron.Cron
+	entries   map[string]cron.EntryID
+
+	History    *state.Store[[]Run]
+	Candidates *state.CandidateStore
+	Quarantine *confluent.QuarantineStore
+	Shrink     *confluent.ShrinkStore
+	Observers  ObserversFunc
+}
+
+func NewScheduler(path string, history *state.Store[[]Run], candidates *state.CandidateStore, quarantine *confluent.QuarantineStore, shrink *confluent.ShrinkStore, observers ObserversFunc) *Scheduler {
+	return &Scheduler{
+		Path:       path,
+		History:    history,
+		Candidates: candidates,
+		Quarantine: quarantine,
+		Shrink:     shrink,
+		Observers:  observers,
+	}
+}
+
+// Start loads the configuration and schedules its jobs
+func (s *Scheduler) Start() error {
+	config, err := LoadConfig(s.Path)
+	if err != nil {
+		return err
+	}
+	s.schedule(config)
+	return nil
+}
+
+// Stop stops scheduling jobs, the running ones are not interrupted
+func (s *Scheduler) Stop() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cron.Stop()
+}
+
+// Reload replaces the jobs with the ones of the configuration file
+func (s *Scheduler) Reload() error {
+	config, err := LoadConfig(s.Path)
+	if err != nil {
+		s.mu.Lock()
+		s.configErr = err
+		s.mu.Unlock()
+		return err
+	}
+	s.schedule(config)
+	return nil
+}
+
+func (s *Scheduler) schedule(config *Config) {
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	entries := make(map[string]cron.EntryID, len(config.Jobs))
+	for _, job := range config.Jobs {
+		job := job
+		// The schedules are validated by LoadConfig
+		id, _ := c.AddFunc(job.Schedule, func() { s.RunJob(config, job) })
+		entries[job.Name] = id
+	}
+	c.Start()
+
+	s.mu.Lock()
+	previous := s.cron
+	s.cron, s.entries, s.config, s.configErr, s.loadedAt = c, entries, config, nil, time.Now()
+	s.mu.Unlock()
+	if previous != nil {
+		previous.Stop()
+	}
+	fmt.Printf("%s scheduler: %d jobs loaded from %s\n", time.Now().Format(time.RFC3339), len(config.Jobs), s.Path)
+}
+
+// Watch reloads the configuration when the file changes, until the context is done
+func (s *Scheduler) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	// The directory is watched, editors replace the file instead of writing it
+	if err := watcher.Add(filepath.Dir(s.Path)); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watcher.Events:
+			if filepath.Clean(event.Name) != filepath.Clean(s.Path) || !event.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+			if err := s.Reload(); err != nil {
+				fmt.Printf("%s scheduler: keeping the previous jobs: %v\n", time.Now().Format(time.RFC3339), err)
+			}
+		case err := <-watcher.Errors:
+			fmt.Printf("%s scheduler: watching %s: %v\n", time.Now().Format(time.RFC3339), s.Path, err)
+		}
+	}
+}
+
+// RunJob runs the cleaners of a job on every cluster and records the run
+func (s *Scheduler) RunJob(config *Config, job JobConfig) Run {
+	run := Run{
+		Id:        fmt.Sprintf("%s-%s", job.Name, time.Now().UTC().Format("20060102T150405Z")),
+		Job:       job.Name,
+		StartedAt: time.Now().UTC(),
+		Apply:     job.Apply,
+		Status:    RunSucceeded,
+		Results:   make([]RunResult, 0),
+	}
+	fmt.Printf("%s run %s: started\n", time.Now().Format(time.RFC3339), run.Id)
+	for _, cluster := range job.Clusters {
+		run.Results = append(run.Results, s.runCluster(config, job, cluster, run.Id)...)
+	}
+	for _, r := range run.Results {
+		if r.Error != "" || r.Failed > 0 {
+			run.Status = RunFailed
+		}
+	}
+	run.FinishedAt = time.Now().UTC()
+	fmt.Printf("%s run %s: %s\n", time.Now().Format(time.RFC3339), run.Id, run.Status)
+
+	err := s.History.Update(func(history map[string][]Run) error {
+		runs := append(history[job.Name], run)
+		if len(runs) > config.History {
+			runs = runs[len(runs)-config.History:]
+		}
+		history[job.Name] = runs
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("%s run %s: recording history: %v\n", time.Now().Format(time.RFC3339), run.Id, err)
+	}
+	return run
+}
+
+func (s *Scheduler) runCluster(config *Config, job JobConfig, cluster ClusterConfig, runId string) []RunResult {
+	failed := func(err error) []RunResult {
+		fmt.Printf("%s run %s: cluster %s: %v\n", time.Now().Format(time.RFC3339), runId, cluster.Id, err)
+		return []RunResult{{Cluster: cluster.Id, Error: err.Error()}}
+	}
+	clean, err := confluent.NewConfluentClean(confluent.Options{
+		Environment:             job.Environment,
+		Cluster:                 cluster.Id,
+		ClusterApiKey:           cluster.ApiKey,
+		ClusterApiSecret:        cluster.ApiSecret,
+		CloudApiKey:             config.CloudApiKey,
+		CloudApiSecret:          config.CloudApiSecret,
+		SchemaRegistryApiKey:    cluster.SchemaRegistryApiKey,
+		SchemaRegistryApiSecret: cluster.SchemaRegistryApiSecret,
+		Endpoints:               config.Endpoints.endpoints(),
+		Prices:                  config.Prices.prices(),
+		Bulk:                    config.Bulk.options(),
+	})
+	if err != nil {
+		return failed(err)
+	}
+	defer clean.Close()
+
+	operator, err := clean.Operator()
+	if err != nil {
+		operator = "api-key:" + config.CloudApiKey
+	}
+	observers, err := s.Observers(clean, cluster, operator, runId)
+	if err != nil {
+		return failed(err)
+	}
+	defer closeObservers(observers)
+
+	results := make([]RunResult, 0, len(job.Cleaners))
+	for _, c := range s.cleaners(clean, job, operator) {
+		results = append(results, runCleaner(c, job.Apply, observers, cluster.Id))
+	}
+	return results
+}
+
+// cleaners returns the cleaners of a job with its policies
+func (s *Scheduler) cleaners(clean *confluent.ConfluentClean, job JobConfig, operator string) []cleaner.Cleaner {
+	cleaners := make([]cleaner.Cleaner, 0, len(job.Cleaners))
+	for _, c := range clean.AllCleaners() {
+		if !slices.Contains(job.Cleaners, c.Name()) {
+			continue
+		}
+		if topics, ok := c.(*confluent.TopicCleaner); ok {
+			switch job.Action {
+			case ActionQuarantine:
+				c = topics.Quarantine(s.Quarantine, append(slices.Clone(job.AdminPrincipals), "User:"+operator))
+			case ActionShrink:
+				c = topics.Shrink(s.Shrink)
+			}
+		}
+		if job.GracePeriod > 0 {
+			c = cleaner.WithGracePeriod(c, s.Candidates, job.GracePeriod)
+		}
+		cleaners = append(cleaners, c)
+	}
+	return cleaners
+}
+
+func runCleaner(c cleaner.Cleaner, apply bool, observers []cleaner.Observer, cluster string) RunResult {
+	ctx := context.Background()
+	result := RunResult{Cluster: cluster, Cleaner: c.Name()}
+	report, err := c.Scan(ctx)
+	if err != nil {
+		result.Error = err.Error()
+		notify(observers, func(o cleaner.Observer) error {
+			if e, ok := o.(cleaner.ErrorObserver); ok {
+				return e.Failed(c.Name(), err)
+			}
+			return nil
+		})
+		return result
+	}
+	for _, w := range report.Warnings {
+		fmt.Printf("%s warning: %s: %s\n", time.Now().Format(time.RFC3339), c.Name(), w)
+	}
+	result.Findings = len(report.Findings)
+	result.Candidates = len(report.Candidates())
+	notify(observers, func(o cleaner.Observer) error { return o.Scanned(report) })
+
+	plan := cleaner.NewPlan(report)
+	notify(observers, func(o cleaner.Observer) error { return o.Planned(plan) })
+	if !apply || len(plan.Findings) == 0 {
+		return result
+	}
+	applied, err := c.Apply(ctx, plan)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Applied = len(applied.Succeeded())
+	result.Failed = len(applied.Failed())
+	notify(observers, func(o cleaner.Observer) error { return o.Applied(applied) })
+	return result
+}
+
+func notify(observers []cleaner.Observer, fn func(o cleaner.Observer) error) {
+	for _, o := range observers {
+		if err := fn(o); err != nil {
+			fmt.Printf("%s warning: %T: %v\n", time.Now().Format(time.RFC3339), o, err)
+		}
+	}
+}
+
+func closeObservers(observers []cleaner.Observer) {
+	for _, o := range observers {
+		if c, ok := o.(interface{ Close() }); ok {
+			c.Close()
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package state
+
+import "time"
+
+// Candidate is a deletion candidate tracked between runs
+type Candidate struct {
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Status    string    `json:"status"`
+}
+
+// CandidateStore keeps the deletion candidates
+type CandidateStore = Store[Candidate]
+
+func NewCandidateStore(path string) *CandidateStore {
+	return NewStore[Candidate](path)
+}
+
+// DefaultCandidatesPath is ~/.cleanup/candidates.json
+func DefaultCandidatesPath() string {
+	return DefaultPath("candidates.json")
+}
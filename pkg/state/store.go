@@ -0,0 +1,81 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store keeps records by key in a local JSON file
+type Store[T any] struct {
+	mu   sync.Mutex
+	Path string
+}
+
+func NewStore[T any](path string) *Store[T] {
+	return &Store[T]{Path: path}
+}
+
+// DefaultPath is ~/.cleanup/<name>
+func DefaultPath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(home, ".cleanup", name)
+}
+
+// Load returns the records, a missing file has no records
+func (s *Store[T]) Load() (map[string]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Update loads the records, applies fn and saves the result
+func (s *Store[T]) Update(fn func(records map[string]T) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(records); err != nil {
+		return err
+	}
+	return s.write(records)
+}
+
+func (s *Store[T]) read() (map[string]T, error) {
+	records := make(map[string]T)
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// write replaces the file atomically
+func (s *Store[T]) write(records map[string]T) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
@@ -0,0 +1,647 @@
+// Package tui is a full-screen browser of the resources of the cleaners.
+// Resources are marked for deletion and the plan is reviewed before it is applied.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"mcolomer/cloud-keeping/pkg/cleaner"
+	"mcolomer/cloud-keeping/pkg/commons"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Tab is a resource list of the browser, backed by a cleaner
+type Tab struct {
+	Title   string
+	Cleaner cleaner.Cleaner
+	Header  []string
+	Row     func(f cleaner.Finding) []interface{}
+	// Details renders additional details of a resource, optional
+	Details func(f cleaner.Finding) (string, error)
+}
+
+// Options of the browser
+type Options struct {
+	Tabs []Tab
+	// Observers are notified of the applied plans, e.g. the audit log
+	Observers []cleaner.Observer
+}
+
+// Run shows the browser until the user quits
+func Run(opts Options) error {
+	_, err := tea.NewProgram(newModel(opts), tea.WithAltScreen()).Run()
+	return err
+}
+
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modeFilter
+	modePlan
+	modeApplying
+	modeResults
+)
+
+var (
+	activeTabStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12")).Underline(true)
+	tabStyle       = lipgloss.NewStyle().Faint(true)
+	headerStyle    = lipgloss.NewStyle().Bold(true)
+	cursorStyle    = lipgloss.NewStyle().Reverse(true)
+	markedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	candidateStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	helpStyle      = lipgloss.NewStyle().Faint(true)
+	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	detailsStyle   = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderTop(true)
+)
+
+// tabState is a tab with its report, filter, sort and marks
+type tabState struct {
+	Tab
+	report  cleaner.Report
+	loaded  bool
+	err     error
+	cells   [][]string
+	visible []int
+	cursor  int
+	offset  int
+	sortCol int
+	desc    bool
+	filter  string
+	marked  map[int]bool
+}
+
+type loadedMsg struct {
+	tab    int
+	report cleaner.Report
+	err    error
+}
+
+type detailsMsg struct {
+	key  string
+	text string
+	err  error
+}
+
+type appliedMsg struct {
+	results  []cleaner.Result
+	warnings []string
+}
+
+type model struct {
+	tabs        []*tabState
+	active      int
+	observers   []cleaner.Observer
+	mode        mode
+	width       int
+	height      int
+	showDetails bool
+	details     map[string]detailsMsg
+	results     []cleaner.Result
+	warnings    []string
+}
+
+func newModel(opts Options) model {
+	m := model{observers: opts.Observers, details: make(map[string]detailsMsg), width: 120, height: 30}
+	for _, t := range opts.Tabs {
+		m.tabs = append(m.tabs, &tabState{Tab: t, sortCol: -1, marked: make(map[int]bool)})
+	}
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	cmds := make([]tea.Cmd, len(m.tabs))
+	for i := range m.tabs {
+		cmds[i] = m.load(i)
+	}
+	return tea.Batch(cmds...)
+}
+
+// load scans the resources of a tab
+func (m model) load(i int) tea.Cmd {
+	c := m.tabs[i].Cleaner
+	return func() tea.Msg {
+		report, err := c.Scan(context.Background())
+		return loadedMsg{tab: i, report: report, err: err}
+	}
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case loadedMsg:
+		t := m.tabs[msg.tab]
+		t.report, t.err, t.loaded = msg.report, msg.err, true
+		t.marked = make(map[int]bool)
+		t.cells = make([][]string, len(t.report.Findings))
+		for i, f := range t.report.Findings {
+			row := t.Row(f)
+			t.cells[i] = make([]string, len(row))
+			for j, cell := range row {
+				t.cells[i][j] = fmt.Sprint(cell)
+			}
+		}
+		t.refresh()
+	case detailsMsg:
+		m.details[msg.key] = msg
+	case appliedMsg:
+		m.results, m.warnings = msg.results, msg.warnings
+		m.mode = modeResults
+	case tea.KeyMsg:
+		return m.key(msg)
+	}
+	return m, nil
+}
+
+func (m model) key(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+	t := m.tabs[m.active]
+	switch m.mode {
+	case modeFilter:
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.mode = modeBrowse
+		case tea.KeyEsc:
+			t.filter = ""
+			t.refresh()
+			m.mode = modeBrowse
+		case tea.KeyBackspace:
+			if len(t.filter) > 0 {
+				t.filter = t.filter[:len(t.filter)-1]
+				t.refresh()
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			t.filter += string(msg.Runes)
+			t.refresh()
+		}
+		return m, nil
+	case modePlan:
+		switch msg.String() {
+		case "y", "enter":
+			m.mode = modeApplying
+			return m, m.apply()
+		case "n", "esc", "q":
+			m.mode = modeBrowse
+		}
+		return m, nil
+	case modeApplying:
+		return m, nil
+	case modeResults:
+		// The applied tabs are scanned again
+		cmds := make([]tea.Cmd, 0)
+		for i, r := range m.tabs {
+			if len(r.marked) > 0 {
+				r.loaded = false
+				cmds = append(cmds, m.load(i))
+			}
+		}
+		m.mode = modeBrowse
+		return m, tea.Batch(cmds...)
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "tab", "right", "l":
+		m.active = (m.active + 1) % len(m.tabs)
+	case "shift+tab", "left", "h":
+		m.active = (m.active + len(m.tabs) - 1) % len(m.tabs)
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if i, _ := strconv.Atoi(msg.String()); i <= len(m.tabs) {
+			m.active = i - 1
+		}
+	case "up", "k":
+		t.move(-1, m.bodyHeight())
+	case "down", "j":
+		t.move(1, m.bodyHeight())
+	case "pgup":
+		t.move(-m.bodyHeight(), m.bodyHeight())
+	case "pgdown":
+		t.move(m.bodyHeight(), m.bodyHeight())
+	case "home", "g":
+		t.move(-len(t.visible), m.bodyHeight())
+	case "end", "G":
+		t.move(len(t.visible), m.bodyHeight())
+	case "/":
+		m.mode = modeFilter
+	case "s":
+		if len(t.Header) > 0 {
+			t.sortCol = (t.sortCol + 1) % len(t.Header)
+			t.refresh()
+		}
+	case "r":
+		t.desc = !t.desc
+		t.refresh()
+	case " ", "x":
+		if i, ok := t.current(); ok {
+			if t.marked[i] {
+				delete(t.marked, i)
+			} else {
+				t.marked[i] = true
+			}
+			t.move(1, m.bodyHeight())
+		}
+	case "c":
+		for _, i := range t.visible {
+			if t.report.Findings[i].Candidate {
+				t.marked[i] = true
+			}
+		}
+	case "u":
+		for _, i := range t.visible {
+			delete(t.marked, i)
+		}
+	case "enter":
+		m.showDetails = !m.showDetails
+	case "p":
+		if m.markedCount() > 0 {
+			m.mode = modePlan
+		}
+	case "R":
+		t.loaded = false
+		return m, m.load(m.active)
+	}
+	if m.showDetails {
+		return m, m.loadDetails()
+	}
+	return m, nil
+}
+
+// loadDetails gets the details of the current resource once
+func (m model) loadDetails() tea.Cmd {
+	t := m.tabs[m.active]
+	i, ok := t.current()
+	if !ok || t.Details == nil {
+		return nil
+	}
+	f := t.report.Findings[i]
+	key := t.Title + "/" + f.Id
+	if _, ok := m.details[key]; ok {
+		return nil
+	}
+	m.details[key] = detailsMsg{key: key, text: "Loading..."}
+	details := t.Details
+	return func() tea.Msg {
+		text, err := details(f)
+		return detailsMsg{key: key, text: text, err: err}
+	}
+}
+
+// apply applies a plan of the marked resources of every tab
+func (m model) apply() tea.Cmd {
+	type job struct {
+		cleaner cleaner.Cleaner
+		plan    cleaner.Plan
+	}
+	jobs := make([]job, 0)
+	for _, t := range m.tabs {
+		if len(t.marked) == 0 {
+			continue
+		}
+		plan := cleaner.NewPlan(t.report)
+		plan.Findings = t.markedFindings()
+		jobs = append(jobs, job{t.Cleaner, plan})
+	}
+	observers := m.observers
+	return func() tea.Msg {
+		msg := appliedMsg{}
+		notify := func(fn func(o cleaner.Observer) error) {
+			for _, o := range observers {
+				if err := fn(o); err != nil {
+					msg.warnings = append(msg.warnings, fmt.Sprintf("%T: %v", o, err))
+				}
+			}
+		}
+		for _, j := range jobs {
+			notify(func(o cleaner.Observer) error { return o.Planned(j.plan) })
+			result, err := j.cleaner.Apply(context.Background(), j.plan)
+			if err != nil {
+				msg.warnings = append(msg.warnings, fmt.Sprintf("applying %s: %v", j.plan.Cleaner, err))
+				continue
+			}
+			notify(func(o cleaner.Observer) error { return o.Applied(result) })
+			msg.results = append(msg.results, result)
+		}
+		return msg
+	}
+}
+
+func (m model) markedCount() int {
+	count := 0
+	for _, t := range m.tabs {
+		count += len(t.marked)
+	}
+	return count
+}
+
+// bodyHeight is the number of rows of the resource list
+func (m model) bodyHeight() int {
+	// The warnings of the scan take a line each
+	h := m.height - 4 - len(m.tabs[m.active].report.Warnings)
+	if m.showDetails {
+		h = h / 2
+	}
+	return max(h, 1)
+}
+
+func (t *tabState) current() (int, bool) {
+	if len(t.visible) == 0 {
+		return 0, false
+	}
+	return t.visible[t.cursor], true
+}
+
+func (t *tabState) move(delta, height int) {
+	t.cursor = max(0, min(len(t.visible)-1, t.cursor+delta))
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+height {
+		t.offset = t.cursor - height + 1
+	}
+}
+
+// refresh filters and sorts the resources
+func (t *tabState) refresh() {
+	t.visible = t.visible[:0]
+	for i, f := range t.report.Findings {
+		if commons.MatchFilter(t.filter, commons.SelectItem{Id: f.Id, Label: strings.Join(t.cells[i], " ")}) {
+			t.visible = append(t.visible, i)
+		}
+	}
+	if t.sortCol >= 0 {
+		sort.SliceStable(t.visible, func(a, b int) bool {
+			x, y := t.cells[t.visible[a]][t.sortCol], t.cells[t.visible[b]][t.sortCol]
+			if t.desc {
+				return compareCells(y, x)
+			}
+			return compareCells(x, y)
+		})
+	}
+	t.cursor, t.offset = 0, 0
+}
+
+func (t *tabState) markedFindings() []cleaner.Finding {
+	findings := make([]cleaner.Finding, 0, len(t.marked))
+	for i, f := range t.report.Findings {
+		if t.marked[i] {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// byteUnits of outputs.Bytes
+var byteUnits = map[string]float64{"B": 1, "KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30, "TB": 1 << 40, "PB": 1 << 50}
+
+// cellValue parses numbers and byte sizes, e.g. 30.0 GB
+func cellValue(cell string) (float64, bool) {
+	if n, err := strconv.ParseFloat(cell, 64); err == nil {
+		return n, true
+	}
+	number, unit, ok := strings.Cut(cell, " ")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || byteUnits[unit] == 0 {
+		return 0, false
+	}
+	return n * byteUnits[unit], true
+}
+
+func compareCells(x, y string) bool {
+	a, okA := cellValue(x)
+	b, okB := cellValue(y)
+	if okA && okB {
+		return a < b
+	}
+	return x < y
+}
+
+// Sparkline renders values as a line of bars
+func Sparkline(values []float64) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	top := 0.0
+	for _, v := range values {
+		top = max(top, v)
+	}
+	line := make([]rune, len(values))
+	for i, v := range values {
+		line[i] = bars[0]
+		if top > 0 {
+			line[i] = bars[int(v/top*float64(len(bars)-1))]
+		}
+	}
+	return string(line)
+}
+
+func (m model) View() string {
+	t := m.tabs[m.active]
+	var b strings.Builder
+	titles := make([]string, len(m.tabs))
+	for i, tab := range m.tabs {
+		title := fmt.Sprintf("%d %s (%d)", i+1, tab.Title, len(tab.report.Findings))
+		if len(tab.marked) > 0 {
+			title += fmt.Sprintf(" *%d", len(tab.marked))
+		}
+		if i == m.active {
+			titles[i] = activeTabStyle.Render(title)
+		} else {
+			titles[i] = tabStyle.Render(title)
+		}
+	}
+	b.WriteString(strings.Join(titles, "   ") + "\n")
+
+	switch m.mode {
+	case modePlan:
+		b.WriteString(m.planView())
+		return b.String()
+	case modeApplying:
+		b.WriteString("\nApplying the plan...\n")
+		return b.String()
+	case modeResults:
+		b.WriteString(m.resultsView())
+		return b.String()
+	}
+
+	// The header and the rows of the table
+	height := m.bodyHeight() + 1
+	switch {
+	case !t.loaded:
+		b.WriteString("Loading " + t.Title + "...\n")
+		height -= 1
+	case t.err != nil:
+		b.WriteString(errorStyle.Render(truncate("Error: "+t.err.Error(), m.width)) + "\n")
+		height -= 1
+	default:
+		b.WriteString(t.tableView(m.bodyHeight(), m.width))
+		height -= 1 + min(len(t.visible)-t.offset, m.bodyHeight())
+	}
+	b.WriteString(strings.Repeat("\n", max(height, 0)))
+
+	if m.showDetails {
+		// The border takes a line
+		b.WriteString(detailsStyle.Width(m.width).Render(m.detailsView(m.height-m.bodyHeight()-5)) + "\n")
+	}
+
+	status := fmt.Sprintf("%d of %d", len(t.visible), len(t.report.Findings))
+	if len(t.visible) > 0 {
+		status = fmt.Sprintf("%d/%d of %d", t.cursor+1, len(t.visible), len(t.report.Findings))
+	}
+	if t.sortCol >= 0 {
+		order := "asc"
+		if t.desc {
+			order = "desc"
+		}
+		status += fmt.Sprintf(" • sort: %s %s", t.Header[t.sortCol], order)
+	}
+	if m.mode == modeFilter || t.filter != "" {
+		status += " • filter: " + t.filter
+		if m.mode == modeFilter {
+			status += "█"
+		}
+	}
+	status += fmt.Sprintf(" • %d marked", m.markedCount())
+	b.WriteString(status + "\n")
+	for _, w := range t.report.Warnings {
+		b.WriteString(errorStyle.Render(truncate("Warning: "+w, m.width)) + "\n")
+	}
+	b.WriteString(helpStyle.Render(truncate("tab: switch • /: filter • s: sort • r: reverse • enter: details • space: mark • c: mark candidates • u: unmark • p: plan • R: reload • q: quit", m.width)))
+	return b.String()
+}
+
+// tableView renders the header and the visible rows of the tab
+func (t *tabState) tableView(height, width int) string {
+	widths := make([]int, len(t.Header))
+	for j, h := range t.Header {
+		widths[j] = len([]rune(h))
+	}
+	for _, row := range t.cells {
+		for j, cell := range row {
+			if j < len(widths) {
+				widths[j] = max(widths[j], len([]rune(cell)))
+			}
+		}
+	}
+	line := func(cells []string) string {
+		padded := make([]string, len(cells))
+		for j, cell := range cells {
+			if j < len(widths) {
+				padded[j] = cell + strings.Repeat(" ", widths[j]-len([]rune(cell)))
+			}
+		}
+		return strings.TrimRight(strings.Join(padded, "  "), " ")
+	}
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(truncate("  "+line(t.Header), width)) + "\n")
+	end := min(len(t.visible), t.offset+height)
+	for row := t.offset; row < end; row++ {
+		i := t.visible[row]
+		mark := "  "
+		if t.marked[i] {
+			mark = "* "
+		}
+		text := truncate(mark+line(t.cells[i]), width)
+		switch {
+		case row == t.cursor:
+			text = cursorStyle.Render(text)
+		case t.marked[i]:
+			text = markedStyle.Render(text)
+		case t.report.Findings[i].Candidate:
+			text = candidateStyle.Render(text)
+		}
+		b.WriteString(text + "\n")
+	}
+	return b.String()
+}
+
+// detailsView renders the attributes and the details of the current resource
+func (m model) detailsView(height int) string {
+	t := m.tabs[m.active]
+	i, ok := t.current()
+	if !ok {
+		return "No resource selected"
+	}
+	f := t.report.Findings[i]
+	lines := []string{headerStyle.Render(f.Id), fmt.Sprintf("Status: %s  Candidate: %t", f.Status, f.Candidate)}
+	names := make([]string, 0, len(f.Attributes))
+	for name := range f.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %s", name, f.Attributes[name]))
+	}
+	if t.Details != nil {
+		details := m.details[t.Title+"/"+f.Id]
+		if details.err != nil {
+			lines = append(lines, errorStyle.Render("Error: "+details.err.Error()))
+		} else if details.text != "" {
+			lines = append(lines, strings.Split(strings.TrimRight(details.text, "\n"), "\n")...)
+		}
+	}
+	if len(lines) > height {
+		lines = append(lines[:max(height-1, 0)], fmt.Sprintf("... %d more lines", len(lines)-height+1))
+	}
+	for j := range lines {
+		lines[j] = truncate(lines[j], m.width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m model) planView() string {
+	var b strings.Builder
+	b.WriteString("\n" + headerStyle.Render("Plan") + "\n")
+	lines := make([]string, 0)
+	for _, t := range m.tabs {
+		findings := t.markedFindings()
+		if len(findings) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %d to apply with %s", t.Title, len(findings), t.Cleaner.Name()))
+		for _, f := range findings {
+			lines = append(lines, fmt.Sprintf("  - %s %s (%s)", f.Kind, f.Id, f.Status))
+		}
+	}
+	if limit := max(m.height-6, 1); len(lines) > limit {
+		lines = append(lines[:limit-1], fmt.Sprintf("  ... %d more", len(lines)-limit+1))
+	}
+	for _, line := range lines {
+		b.WriteString(truncate(line, m.width) + "\n")
+	}
+	b.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Apply the plan of %d resources? (y/N)", m.markedCount())))
+	return b.String()
+}
+
+func (m model) resultsView() string {
+	var b strings.Builder
+	b.WriteString("\n" + headerStyle.Render("Results") + "\n")
+	for _, r := range m.results {
+		fmt.Fprintf(&b, "%s: %d succeeded, %d failed\n", r.Cleaner, len(r.Succeeded()), len(r.Failed()))
+		for _, item := range r.Failed() {
+			b.WriteString(errorStyle.Render(truncate(fmt.Sprintf("  - %s %s: %v", item.Kind, item.Id, item.Err), m.width)) + "\n")
+		}
+	}
+	for _, w := range m.warnings {
+		b.WriteString(errorStyle.Render(truncate("Warning: "+w, m.width)) + "\n")
+	}
+	b.WriteString("\n" + helpStyle.Render("Press any key to reload"))
+	return b.String()
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}